@@ -2,39 +2,75 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"log"
+	"time"
 
 	"bitbucket.org/agrim123/onyx/pkg/core/cloudwatch"
+	"bitbucket.org/agrim123/onyx/pkg/logger"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/spf13/cobra"
 )
 
+var (
+	cloudwatchRulePrefix        string
+	cloudwatchRuleTargetCluster string
+	cloudwatchRuleTags          []string
+	cloudwatchSnapshotPath      string
+	cloudwatchScheduleCount     int
+	cloudwatchScheduleTimezone  string
+	cloudwatchScheduledTask     cloudwatch.ScheduledTaskInput
+	cloudwatchAlarmPrefix       string
+	cloudwatchAlarmDimensions   []string
+	cloudwatchAlarmsAll         bool
+	cloudwatchMuteDuration      time.Duration
+)
+
 var cloudwatchCommand = &cobra.Command{
 	Use:   "cw",
 	Short: "Actions to be performed on Cloudwatch",
 }
 
 var cloudwatchDisableRuleCommand = &cobra.Command{
-	Use:     "disable <name>",
-	Short:   "Disables cloudwatch rule",
+	Use:     "disable <name> | --prefix <prefix> [--tag KEY=VALUE] [--snapshot <file>]",
+	Short:   "Disables cloudwatch rules",
+	Long:    `Disables a single rule by name, or every rule matching --prefix and all --tag filters. For bulk disables, the prior states of the matched rules are written to a snapshot file which can be passed to ` + "`onyx cw restore`" + `.`,
 	Args:    cobra.MaximumNArgs(1),
-	Example: "onyx cw disable SomRule",
+	Example: "onyx cw disable SomeRule\nonyx cw disable --prefix nightly- --tag team=data",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		bulk := cloudwatchRulePrefix != "" || len(cloudwatchRuleTags) > 0
+		if len(args) == 1 && bulk {
+			return errors.New("provide either a rule name or --prefix/--tag, not both")
+		}
+
+		if len(args) == 0 && !bulk {
+			return errors.New("provide a rule name, --prefix or --tag")
+		}
+
 		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
 		if err != nil {
 			log.Fatalf("unable to load SDK config, %v", err)
 		}
 		ctx := context.Background()
 
-		return cloudwatch.DisableRule(ctx, cfg, args[0])
+		if len(args) == 1 {
+			return cloudwatch.DisableRule(ctx, cfg, args[0])
+		}
+
+		snapshotPath := cloudwatchSnapshotPath
+		if snapshotPath == "" {
+			snapshotPath = cloudwatch.DefaultSnapshotPath()
+		}
+
+		return cloudwatch.DisableRules(ctx, cfg, cloudwatchRulePrefix, cloudwatchRuleTags, snapshotPath)
 	},
 }
 
 var cloudwatchEnableRuleCommand = &cobra.Command{
 	Use:     "enable <name>",
 	Short:   "Enables cloudwatch rule",
-	Args:    cobra.MaximumNArgs(1),
-	Example: "onyx cw disable SomRule",
+	Args:    cobra.ExactArgs(1),
+	Example: "onyx cw enable SomeRule",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
 		if err != nil {
@@ -46,6 +82,239 @@ var cloudwatchEnableRuleCommand = &cobra.Command{
 	},
 }
 
+var cloudwatchRestoreRulesCommand = &cobra.Command{
+	Use:     "restore <snapshot>",
+	Short:   "Re-enables cloudwatch rules recorded as enabled in a snapshot",
+	Long:    `Re-enables exactly the rules that were enabled when the snapshot was taken by a bulk ` + "`onyx cw disable`" + `. Rules that were already disabled are left untouched.`,
+	Args:    cobra.ExactArgs(1),
+	Example: "onyx cw restore cw-rules-snapshot-20210601-220000.json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		return cloudwatch.RestoreRules(ctx, cfg, args[0])
+	},
+}
+
+var cloudwatchRulesCommand = &cobra.Command{
+	Use:   "rules",
+	Short: "Actions to be performed on Cloudwatch rules",
+}
+
+var cloudwatchListRulesCommand = &cobra.Command{
+	Use:     "list [--prefix <prefix>] [--target-cluster <cluster-name>]",
+	Short:   "Lists cloudwatch rules with their schedule, state and targets",
+	Long:    `Lists cloudwatch rules with their schedule expression, state and targets. ECS task targets are shown with their task definition and cluster.`,
+	Args:    cobra.NoArgs,
+	Example: "onyx cw rules list --prefix nightly-\nonyx cw rules list --target-cluster staging-api-cluster",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		return cloudwatch.PrintRules(ctx, cfg, cloudwatchRulePrefix, cloudwatchRuleTargetCluster)
+	},
+}
+
+var cloudwatchRunRuleCommand = &cobra.Command{
+	Use:     "run-now <name>",
+	Short:   "Runs the ECS targets of a cloudwatch rule immediately",
+	Long:    `Launches the ECS task targets of the rule right away with the same task definition, network configuration, launch type and container overrides the rule would use.`,
+	Args:    cobra.ExactArgs(1),
+	Example: "onyx cw rules run-now nightly-report",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		return cloudwatch.RunRuleNow(ctx, cfg, args[0])
+	},
+}
+
+var cloudwatchPutRuleCommand = &cobra.Command{
+	Use:     "put --name <name> --schedule <expression> --cluster <cluster-name> --task-def <family[:revision]> --role-arn <role-arn> [--from-service <service-name>] [--command <command>]",
+	Short:   "Creates or updates a scheduled rule running an ECS task",
+	Long:    `Creates or updates a scheduled rule and its ECS task target. The schedule expression is validated locally. Network configuration and launch type are copied from --from-service, or from a service of the cluster running the same task definition family. Without a revision, the rule runs the latest revision of the family. When updating a rule, the command of its existing target for the family is kept unless --command is given. The role must allow events.amazonaws.com to run the task.`,
+	Args:    cobra.NoArgs,
+	Example: "onyx cw rules put --name nightly-report --schedule \"cron(30 18 ? * MON-FRI *)\" --cluster staging-api-cluster --task-def report --role-arn arn:aws:iam::123456789012:role/ecsEventsRole\nonyx cw rules put --name cache-warmup --schedule \"rate(1 hour)\" --cluster staging-api-cluster --task-def api --from-service api --command \"rake cache:warm\" --role-arn arn:aws:iam::123456789012:role/ecsEventsRole",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		return cloudwatch.PutScheduledTask(ctx, cfg, cloudwatchScheduledTask)
+	},
+}
+
+var cloudwatchDeleteRuleCommand = &cobra.Command{
+	Use:     "delete <name>",
+	Short:   "Deletes a cloudwatch rule along with its targets",
+	Args:    cobra.ExactArgs(1),
+	Example: "onyx cw rules delete nightly-report",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		return cloudwatch.DeleteRule(ctx, cfg, args[0])
+	},
+}
+
+var cloudwatchScheduleCommand = &cobra.Command{
+	Use:   "schedule",
+	Short: "Actions on cloudwatch schedule expressions",
+}
+
+var cloudwatchScheduleNextCommand = &cobra.Command{
+	Use:     "next <rule-name|\"cron(...)\"|\"rate(...)\"> [--count 10] [--tz <timezone>]",
+	Short:   "Prints upcoming fire times of a rule or schedule expression",
+	Long:    `Parses the AWS six field cron (minutes hours day-of-month month day-of-week year) or rate expression locally and prints its upcoming fire times. A rule name is looked up for its schedule expression. AWS evaluates cron expressions in UTC, --tz only changes how the times are displayed.`,
+	Args:    cobra.ExactArgs(1),
+	Example: "onyx cw schedule next nightly-report\nonyx cw schedule next \"cron(0 18 ? * MON-FRI *)\" --tz Asia/Kolkata\nonyx cw schedule next \"cron(0 10 L * ? *)\" --count 3",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		location := time.Local
+		if cloudwatchScheduleTimezone != "" {
+			var err error
+			location, err = time.LoadLocation(cloudwatchScheduleTimezone)
+			if err != nil {
+				return errors.New("invalid timezone " + cloudwatchScheduleTimezone)
+			}
+		}
+
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		return cloudwatch.PrintNextFireTimes(ctx, cfg, args[0], cloudwatchScheduleCount, location)
+	},
+}
+
+var cloudwatchAlarmsCommand = &cobra.Command{
+	Use:     "alarms [--prefix <prefix>] [--dimension NAME=VALUE] [--all]",
+	Short:   "Lists cloudwatch alarms in ALARM or INSUFFICIENT_DATA state",
+	Long:    `Lists metric alarms in ALARM or INSUFFICIENT_DATA state (any state with --all), filtered by name prefix and dimensions, along with whether their actions are enabled or muted.`,
+	Args:    cobra.NoArgs,
+	Example: "onyx cw alarms --prefix api-\nonyx cw alarms --dimension ClusterName=staging-api-cluster --dimension ServiceName=api",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		return cloudwatch.PrintAlarms(ctx, cfg, cloudwatchAlarmPrefix, cloudwatchAlarmDimensions, cloudwatchAlarmsAll)
+	},
+}
+
+var cloudwatchMuteAlarmsCommand = &cobra.Command{
+	Use:     "mute --prefix <prefix> | --dimension NAME=VALUE --for <duration>",
+	Short:   "Disables alarm actions for a while",
+	Long:    `Disables the actions of every alarm matching the prefix and dimensions and records the mute window under ~/.onyx. Actions are re-enabled by ` + "`onyx cw alarms unmute`" + `, or by any onyx command run after the window has passed.`,
+	Args:    cobra.NoArgs,
+	Example: "onyx cw alarms mute --prefix api- --for 2h\nonyx cw alarms mute --dimension ServiceName=api --for 30m",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		return cloudwatch.MuteAlarms(ctx, cfg, cloudwatchAlarmPrefix, cloudwatchAlarmDimensions, cloudwatchMuteDuration)
+	},
+}
+
+var cloudwatchUnmuteAlarmsCommand = &cobra.Command{
+	Use:     "unmute [--prefix <prefix>]",
+	Short:   "Re-enables actions of alarms muted by onyx",
+	Args:    cobra.NoArgs,
+	Example: "onyx cw alarms unmute\nonyx cw alarms unmute --prefix api-",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		return cloudwatch.UnmuteAlarms(ctx, cfg, cloudwatchAlarmPrefix)
+	},
+}
+
+// unmuteExpiredAlarms re-enables actions of alarms whose mute window has passed. AWS is only called when there are any.
+// It is best effort and never stops the command being run.
+func unmuteExpiredAlarms() {
+	if !cloudwatch.HasExpiredAlarmMutes() {
+		return
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+	if err != nil {
+		logger.Warn("Alarm mute window has passed but unable to load SDK config to unmute. Error: %s", err.Error())
+		return
+	}
+	ctx := context.Background()
+
+	logger.Info("Alarm mute window has passed, unmuting")
+	if err := cloudwatch.UnmuteExpiredAlarms(ctx, cfg); err != nil {
+		logger.Warn("Unable to unmute alarms. Error: %s", err.Error())
+	}
+}
+
 func init() {
-	cloudwatchCommand.AddCommand(cloudwatchDisableRuleCommand, cloudwatchEnableRuleCommand)
+	cloudwatchCommand.AddCommand(cloudwatchDisableRuleCommand, cloudwatchEnableRuleCommand, cloudwatchRestoreRulesCommand, cloudwatchRulesCommand, cloudwatchScheduleCommand, cloudwatchAlarmsCommand)
+
+	cloudwatchRulesCommand.AddCommand(cloudwatchListRulesCommand, cloudwatchRunRuleCommand, cloudwatchPutRuleCommand, cloudwatchDeleteRuleCommand)
+
+	cloudwatchScheduleCommand.AddCommand(cloudwatchScheduleNextCommand)
+
+	cloudwatchAlarmsCommand.AddCommand(cloudwatchMuteAlarmsCommand, cloudwatchUnmuteAlarmsCommand)
+
+	cloudwatchListRulesCommand.Flags().StringVarP(&cloudwatchRulePrefix, "prefix", "p", "", "Only lists rules whose name starts with prefix")
+	cloudwatchListRulesCommand.Flags().StringVar(&cloudwatchRuleTargetCluster, "target-cluster", "", "Only lists rules with an ECS target in this cluster")
+
+	cloudwatchDisableRuleCommand.Flags().StringVarP(&cloudwatchRulePrefix, "prefix", "p", "", "Disables every rule whose name starts with prefix")
+	cloudwatchDisableRuleCommand.Flags().StringArrayVarP(&cloudwatchRuleTags, "tag", "t", nil, "Only disables rules having this tag, as KEY=VALUE. Can be repeated")
+	cloudwatchDisableRuleCommand.Flags().StringVar(&cloudwatchSnapshotPath, "snapshot", "", "File to write prior rule states to. Defaults to cw-rules-snapshot-<timestamp>.json")
+
+	cloudwatchScheduleNextCommand.Flags().IntVarP(&cloudwatchScheduleCount, "count", "n", 10, "Number of fire times to print")
+	cloudwatchScheduleNextCommand.Flags().StringVar(&cloudwatchScheduleTimezone, "tz", "", "Timezone to display fire times in, e.g. Asia/Kolkata. Defaults to local time")
+
+	cloudwatchPutRuleCommand.Flags().StringVar(&cloudwatchScheduledTask.Name, "name", "", "Rule Name (required)")
+	cloudwatchPutRuleCommand.MarkFlagRequired("name")
+	cloudwatchPutRuleCommand.Flags().StringVar(&cloudwatchScheduledTask.Schedule, "schedule", "", "Schedule expression, cron(...) or rate(...) (required)")
+	cloudwatchPutRuleCommand.MarkFlagRequired("schedule")
+	cloudwatchPutRuleCommand.Flags().StringVarP(&cloudwatchScheduledTask.ClusterName, "cluster", "c", "", "Cluster Name (required)")
+	cloudwatchPutRuleCommand.MarkFlagRequired("cluster")
+	cloudwatchPutRuleCommand.Flags().StringVar(&cloudwatchScheduledTask.TaskDefinition, "task-def", "", "Task definition family, optionally with revision (required)")
+	cloudwatchPutRuleCommand.MarkFlagRequired("task-def")
+	cloudwatchPutRuleCommand.Flags().StringVar(&cloudwatchScheduledTask.RoleArn, "role-arn", "", "Role used by cloudwatch events to run the task (required)")
+	cloudwatchPutRuleCommand.MarkFlagRequired("role-arn")
+	cloudwatchPutRuleCommand.Flags().StringVar(&cloudwatchScheduledTask.ServiceName, "from-service", "", "Service to copy network configuration and launch type from")
+	cloudwatchPutRuleCommand.Flags().StringVar(&cloudwatchScheduledTask.Description, "description", "", "Rule description")
+	cloudwatchPutRuleCommand.Flags().StringVar(&cloudwatchScheduledTask.ContainerName, "container", "", "Container to override the command of. Defaults to the first essential container")
+	cloudwatchPutRuleCommand.Flags().StringVar(&cloudwatchScheduledTask.Command, "command", "", "Command to run instead of the container's default")
+	cloudwatchPutRuleCommand.Flags().Int32Var(&cloudwatchScheduledTask.Count, "count", 1, "Number of tasks to run on each schedule")
+
+	cloudwatchAlarmsCommand.Flags().StringVarP(&cloudwatchAlarmPrefix, "prefix", "p", "", "Only lists alarms whose name starts with prefix")
+	cloudwatchAlarmsCommand.Flags().StringArrayVarP(&cloudwatchAlarmDimensions, "dimension", "d", nil, "Only lists alarms with this dimension, as NAME=VALUE. Can be repeated")
+	cloudwatchAlarmsCommand.Flags().BoolVarP(&cloudwatchAlarmsAll, "all", "a", false, "Lists alarms in any state")
+
+	cloudwatchMuteAlarmsCommand.Flags().StringVarP(&cloudwatchAlarmPrefix, "prefix", "p", "", "Mutes alarms whose name starts with prefix")
+	cloudwatchMuteAlarmsCommand.Flags().StringArrayVarP(&cloudwatchAlarmDimensions, "dimension", "d", nil, "Only mutes alarms with this dimension, as NAME=VALUE. Can be repeated")
+	cloudwatchMuteAlarmsCommand.Flags().DurationVar(&cloudwatchMuteDuration, "for", 0, "How long to mute alarms for, e.g. 30m or 2h (required)")
+	cloudwatchMuteAlarmsCommand.MarkFlagRequired("for")
+
+	cloudwatchUnmuteAlarmsCommand.Flags().StringVarP(&cloudwatchAlarmPrefix, "prefix", "p", "", "Only unmutes alarms whose name starts with prefix")
 }
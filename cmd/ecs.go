@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"log"
+	"time"
 
 	"bitbucket.org/agrim123/onyx/pkg/core/ecs"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -12,10 +13,42 @@ import (
 
 var ecsClusterName string
 var ecsServiceName string
+var ecsSchedulingStrategy string
+var ecsServiceMatch string
+var ecsRunCommand string
+var ecsRunEnvironment []string
+var ecsRunWait bool
+var ecsRunLogs bool
+var ecsRunTimeout time.Duration
+var ecsEnvResolve bool
+var ecsEnvShow bool
+var ecsContainerName string
+var ecsRollbackRevision int32
+var ecsScaleDesired int32
+var ecsContainerInstance string
+var ecsDrainTerminate bool
+var ecsAgentUpdateBatch int
+var ecsAgentUpdateWait bool
+var ecsLogsSince time.Duration
+var ecsLogsFollow bool
+var ecsLogsGrep string
+var ecsLimit int
+var ecsTaskDefinition string
+var ecsCapacityCount int32
+var ecsScaleMin int32
+var ecsScaleMax int32
+var ecsRollbackForce bool
+var ecsMetricsPeriod time.Duration
+var ecsMetricsSince time.Duration
+var ecsOutput string
 
 var ecsCommand = &cobra.Command{
 	Use:   "ecs",
 	Short: "Actions to be performed on ECS clusters",
+	Long:  `Actions to be performed on ECS clusters. Services given with --service are matched exactly by default, use --match to match them by prefix, fuzzily or by regular expression.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return ecs.SetMatchMode(ecsServiceMatch)
+	},
 }
 
 var ecsDescribeCommand = &cobra.Command{
@@ -35,14 +68,19 @@ var ecsDescribeCommand = &cobra.Command{
 			return errors.New("empty cluster name")
 		}
 
-		return ecs.Describe(ctx, cfg, ecsServiceName, ecsClusterName)
+		strategy, err := ecs.ParseSchedulingStrategy(ecsSchedulingStrategy)
+		if err != nil {
+			return err
+		}
+
+		return ecs.Describe(ctx, cfg, ecsServiceName, ecsClusterName, strategy)
 	},
 }
 
 var ecsRestartServiceCommand = &cobra.Command{
 	Use:     "restart --cluster <cluster-name> [--service <service-name>]",
 	Short:   "Forces new deployment of ECS services",
-	Long:    `Triggers redployment of the chosen services of a cluster. If service name is provided it restarts only the exact matching input, else fails.`,
+	Long:    `Triggers redeployment of the chosen services of a cluster. If service name is provided only services matching it (see --match) are listed, else all services of the cluster. Choices accept indexes, ranges and all, example: 0,2 or 0-3 or all.`,
 	Example: "onyx ecs restart --cluster staging-api-cluster\nonyx ecs restart --cluster staging-api-cluster --service some_service",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
@@ -51,15 +89,172 @@ var ecsRestartServiceCommand = &cobra.Command{
 		}
 		ctx := context.Background()
 
-		return ecs.RedeployService(ctx, cfg, ecsClusterName, ecsServiceName)
+		strategy, err := ecs.ParseSchedulingStrategy(ecsSchedulingStrategy)
+		if err != nil {
+			return err
+		}
+
+		return ecs.RedeployService(ctx, cfg, ecsClusterName, ecsServiceName, strategy)
 	},
 }
 
 var ecsUpdateContainerInstanceCommand = &cobra.Command{
-	Use:     "update-agent",
-	Short:   "Updates container agents for all attached container instances",
-	Long:    ``,
-	Example: "onyx ecs update-agent",
+	Use:     "update-agent --cluster <cluster-name> [--batch <size>] [--wait]",
+	Short:   "Updates container agents of the container instances of a cluster",
+	Long:    `Updates the container agent of every container instance of the cluster, skipping instances already at the latest agent or with an update in progress. Updates are requested in rolling batches, each batch is polled until UPDATED or FAILED before the next one starts. With --wait, the last batch is waited for as well. Prints a per-instance report at the end and fails if any update failed.`,
+	Args:    cobra.NoArgs,
+	Example: "onyx ecs update-agent --cluster staging-api-cluster\nonyx ecs update-agent --cluster staging-api-cluster --batch 2 --wait",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		return ecs.UpdateContainerAgent(ctx, cfg, ecsClusterName, ecsAgentUpdateBatch, ecsAgentUpdateWait)
+	},
+}
+
+var ecsRollbackServiceCommand = &cobra.Command{
+	Use:     "rollback --cluster <cluster-name> --service <service-name> [--to-revision <revision>] [--force]",
+	Short:   "Rolls back ECS service to an older task definition revision",
+	Long:    `Lists recent revisions of the service's task definition family and updates the service to the chosen one, defaulting to the previous revision. Waits for the service to reach steady state. INACTIVE revisions are refused unless forced.`,
+	Args:    cobra.NoArgs,
+	Example: "onyx ecs rollback --cluster staging-api-cluster --service some_service\nonyx ecs rollback --cluster staging-api-cluster --service some_service --to-revision 42",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		return ecs.RollbackService(ctx, cfg, ecsClusterName, ecsServiceName, ecsRollbackRevision, ecsRollbackForce)
+	},
+}
+
+var ecsTaskDefinitionCommand = &cobra.Command{
+	Use:   "taskdef",
+	Short: "Actions to be performed on ECS task definitions",
+}
+
+var ecsTaskDefinitionDiffCommand = &cobra.Command{
+	Use:     "diff {<family:revision> <family:revision> | --cluster <cluster-name> --service <service-name>}",
+	Short:   "Shows differences between two task definitions",
+	Long:    `Compares containers, images, environment variables, secret references, CPU/memory, port mappings and log configuration of two task definition revisions. With --cluster and --service, compares the service's current revision with the previous one.`,
+	Args:    cobra.MaximumNArgs(2),
+	Example: "onyx ecs taskdef diff api:41 api:42\nonyx ecs taskdef diff --cluster staging-api-cluster --service some_service",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		if len(args) == 2 {
+			return ecs.DiffTaskDefinitionRevisions(ctx, cfg, args[0], args[1])
+		}
+
+		if ecsClusterName == "" || ecsServiceName == "" {
+			return errors.New("either two task definitions or `--cluster` and `--service` are required")
+		}
+
+		return ecs.DiffServiceTaskDefinition(ctx, cfg, ecsClusterName, ecsServiceName)
+	},
+}
+
+var ecsExecCommand = &cobra.Command{
+	Use:     "exec --cluster <cluster-name> --service <service-name> [--container <container-name>] [-- command]",
+	Short:   "Opens an interactive session in a running task of a service",
+	Long:    `Resolves a running task of the chosen service and starts an ECS exec session via the session-manager-plugin. Runs /bin/sh when no command is given. Checks beforehand that the service has execute command enabled and its task role allows the required ssmmessages actions.`,
+	Example: "onyx ecs exec --cluster staging-api-cluster --service some_service\nonyx ecs exec --cluster staging-api-cluster --service some_service --container app -- /bin/bash",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		if len(args) == 0 {
+			args = []string{"/bin/sh"}
+		}
+
+		return ecs.ExecuteCommand(ctx, cfg, ecsClusterName, ecsServiceName, ecsContainerName, args)
+	},
+}
+
+var ecsScaleServiceCommand = &cobra.Command{
+	Use:     "scale --cluster <cluster-name> --service <service-name> --desired <count> [--min <count>] [--max <count>]",
+	Short:   "Updates desired count of an ECS service",
+	Long:    `Updates the desired count of the service. If the service has an application autoscaling target, its min and max capacity are adjusted to include the desired count (or set to --min/--max) so the scaler does not override it.`,
+	Args:    cobra.NoArgs,
+	Example: "onyx ecs scale --cluster staging-api-cluster --service some_service --desired 4\nonyx ecs scale --cluster staging-api-cluster --service some_service --desired 4 --min 2 --max 8",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		return ecs.ScaleService(ctx, cfg, ecsClusterName, ecsServiceName, ecsScaleDesired, ecsScaleMin, ecsScaleMax)
+	},
+}
+
+var ecsDrainCommand = &cobra.Command{
+	Use:     "drain --cluster <cluster-name> --instance <container-instance | ec2-instance-id> [--terminate]",
+	Short:   "Drains a container instance",
+	Long:    `Sets the container instance to DRAINING and waits until its service tasks are rescheduled elsewhere and the services run their desired count again. Standalone tasks are not moved by ECS, so they are listed to be stopped or waited for. With --terminate, the ec2 instance is terminated afterwards, letting its auto scaling group replace it. Termination is confirmed before draining starts.`,
+	Args:    cobra.NoArgs,
+	Example: "onyx ecs drain --cluster staging-api-cluster --instance i-0asd68a8120u\nonyx ecs drain --cluster staging-api-cluster --instance i-0asd68a8120u --terminate",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		return ecs.DrainContainerInstance(ctx, cfg, ecsClusterName, ecsContainerInstance, ecsDrainTerminate)
+	},
+}
+
+var ecsLogsCommand = &cobra.Command{
+	Use:     "logs --cluster <cluster-name> --service <service-name> [--container <container-name>] [--since <duration>] [--follow] [--grep <pattern>]",
+	Short:   "Prints CloudWatch logs of the running tasks of a service",
+	Long:    `Reads the awslogs configuration from the service's task definition, resolves the log stream of each running task and prints their events interleaved, prefixed with the task id. With --follow, tasks started later, e.g. by a deployment, are picked up as well.`,
+	Args:    cobra.NoArgs,
+	Example: "onyx ecs logs --cluster staging-api-cluster --service some_service\nonyx ecs logs --cluster staging-api-cluster --service some_service --since 1h --grep ERROR --follow",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		return ecs.ServiceLogs(ctx, cfg, ecsClusterName, ecsServiceName, ecsContainerName, ecsLogsSince, ecsLogsFollow, ecsLogsGrep)
+	},
+}
+
+var ecsEventsCommand = &cobra.Command{
+	Use:     "events --cluster <cluster-name> --service <service-name> [--limit <count>]",
+	Short:   "Shows the event stream of an ECS service",
+	Args:    cobra.NoArgs,
+	Example: "onyx ecs events --cluster staging-api-cluster --service some_service",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		return ecs.ServiceEvents(ctx, cfg, ecsClusterName, ecsServiceName, ecsLimit)
+	},
+}
+
+var ecsStoppedTasksCommand = &cobra.Command{
+	Use:     "stopped --cluster <cluster-name> --service <service-name> [--limit <count>]",
+	Short:   "Lists recently stopped tasks of an ECS service",
+	Long:    `Lists recently stopped tasks of the service with their stop code, stopped reason and the exit code and reason of each container, along with a diagnosis for common failures like OOM, health check failures and image pull errors.`,
+	Args:    cobra.NoArgs,
+	Example: "onyx ecs stopped --cluster staging-api-cluster --service some_service",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
 		if err != nil {
@@ -67,17 +262,267 @@ var ecsUpdateContainerInstanceCommand = &cobra.Command{
 		}
 		ctx := context.Background()
 
-		return ecs.UpdateContainerAgent(ctx, cfg)
+		return ecs.StoppedTasks(ctx, cfg, ecsClusterName, ecsServiceName, ecsLimit)
+	},
+}
+
+var ecsCapacityCommand = &cobra.Command{
+	Use:     "capacity --cluster <cluster-name> [--task-def <family[:revision]>] [--count <count>]",
+	Short:   "Shows capacity and placement overview of an ECS cluster",
+	Long:    `Lists every container instance of the cluster with its type, availability zone, agent version, running tasks and free vs registered CPU/memory. With --task-def, also reports how many more copies of that task definition can be placed.`,
+	Args:    cobra.NoArgs,
+	Example: "onyx ecs capacity --cluster staging-api-cluster\nonyx ecs capacity --cluster staging-api-cluster --task-def api --count 4",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		return ecs.ClusterCapacity(ctx, cfg, ecsClusterName, ecsTaskDefinition, ecsCapacityCount)
+	},
+}
+
+var ecsListClustersCommand = &cobra.Command{
+	Use:     "clusters [--cluster <name-filter>]",
+	Short:   "Lists ECS clusters with a health summary",
+	Long:    `Lists clusters by name with counts of active services, running and pending tasks, registered container instances and capacity providers.`,
+	Args:    cobra.NoArgs,
+	Example: "onyx ecs clusters\nonyx ecs clusters --cluster staging",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		return ecs.PrintClusters(ctx, cfg, ecsClusterName)
+	},
+}
+
+var ecsListServicesCommand = &cobra.Command{
+	Use:     "services --cluster <cluster-name>",
+	Short:   "Lists services of an ECS cluster with their health",
+	Long:    `Lists every service of the cluster with desired, running and pending counts and deployment state. Services running fewer tasks than desired are highlighted.`,
+	Args:    cobra.NoArgs,
+	Example: "onyx ecs services --cluster staging-api-cluster",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		strategy, err := ecs.ParseSchedulingStrategy(ecsSchedulingStrategy)
+		if err != nil {
+			return err
+		}
+
+		cluster := ecs.Cluster{
+			Name:               ecsClusterName,
+			SchedulingStrategy: strategy,
+		}
+
+		if err := cluster.GetServices(ctx, cfg, ""); err != nil {
+			return err
+		}
+
+		cluster.PrintServices()
+		return nil
+	},
+}
+
+var ecsRunTaskCommand = &cobra.Command{
+	Use:     "run --cluster <cluster-name> --from-service <service-name> [--container <container-name>] [--command <command>] [--env KEY=VALUE] [--wait] [--logs]",
+	Short:   "Runs a one-off task from a service's configuration",
+	Long:    `Launches a standalone task using the service's task definition, network configuration and launch type, overriding the command and environment of a container (the first essential one by default). With --wait or --logs, waits for the task to stop and exits with the container's exit code.`,
+	Args:    cobra.NoArgs,
+	Example: "onyx ecs run --cluster staging-api-cluster --from-service api --command \"rake db:migrate\" --logs\nonyx ecs run --cluster staging-api-cluster --from-service api --command \"rake cache:clear\" --env RAILS_ENV=staging --wait",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		exitCode, err := ecs.RunTaskFromService(ctx, cfg, ecs.RunTaskInput{
+			ClusterName:   ecsClusterName,
+			ServiceName:   ecsServiceName,
+			ContainerName: ecsContainerName,
+			Command:       ecsRunCommand,
+			Environment:   ecsRunEnvironment,
+			Wait:          ecsRunWait,
+			Logs:          ecsRunLogs,
+			Timeout:       ecsRunTimeout,
+		})
+		if err != nil {
+			return err
+		}
+
+		if exitCode != 0 {
+			return exitWithCode(cmd, int(exitCode))
+		}
+
+		return nil
+	},
+}
+
+var ecsEnvCommand = &cobra.Command{
+	Use:     "env --cluster <cluster-name> --service <service-name> [--container <container-name>] [--resolve [--show]]",
+	Short:   "Shows environment variables and secrets of an ECS service",
+	Long:    `Shows the environment variables, environment files and secret references (SSM parameters and Secrets Manager ARNs) of the containers of the service's current task definition. With --resolve, the referenced secret values are fetched and printed masked unless --show is given.`,
+	Args:    cobra.NoArgs,
+	Example: "onyx ecs env --cluster staging-api-cluster --service some_service\nonyx ecs env --cluster staging-api-cluster --service some_service --container app --resolve",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		return ecs.ServiceEnvironment(ctx, cfg, ecsClusterName, ecsServiceName, ecsContainerName, ecsEnvResolve, ecsEnvShow)
+	},
+}
+
+var ecsMetricsCommand = &cobra.Command{
+	Use:     "metrics --cluster <cluster-name> --service <service-name> [--period 5m] [--since 3h] [--output table|json]",
+	Short:   "Shows CPU and memory utilization of an ECS service",
+	Long:    `Fetches CPUUtilization and MemoryUtilization of the service from CloudWatch and renders min, avg, max and last values along with a sparkline of the averages. Use --output json to graph them elsewhere.`,
+	Args:    cobra.NoArgs,
+	Example: "onyx ecs metrics --cluster staging-api-cluster --service api\nonyx ecs metrics --cluster staging-api-cluster --service api --period 1m --since 30m --output json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if ecsOutput != "table" && ecsOutput != "json" {
+			return errors.New("invalid output " + ecsOutput + ". Allowed values table|json")
+		}
+
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		metrics, err := ecs.GetServiceMetrics(ctx, cfg, ecsClusterName, ecsServiceName, ecsMetricsPeriod, ecsMetricsSince)
+		if err != nil {
+			return err
+		}
+
+		if ecsOutput == "json" {
+			return metrics.PrintJSON()
+		}
+
+		metrics.Print()
+		return nil
 	},
 }
 
 func init() {
-	ecsCommand.AddCommand(ecsDescribeCommand, ecsRestartServiceCommand, ecsUpdateContainerInstanceCommand)
+	ecsCommand.PersistentFlags().StringVarP(&ecsServiceMatch, "match", "m", "exact", "How --service is matched against service names. Allowed values exact|prefix|fuzzy|regex")
+
+	ecsCommand.AddCommand(ecsDescribeCommand, ecsRestartServiceCommand, ecsUpdateContainerInstanceCommand, ecsRollbackServiceCommand, ecsTaskDefinitionCommand, ecsExecCommand, ecsScaleServiceCommand, ecsDrainCommand, ecsLogsCommand, ecsEventsCommand, ecsStoppedTasksCommand, ecsCapacityCommand, ecsListClustersCommand, ecsListServicesCommand, ecsRunTaskCommand, ecsEnvCommand, ecsMetricsCommand)
+
+	ecsTaskDefinitionCommand.AddCommand(ecsTaskDefinitionDiffCommand)
 
 	ecsRestartServiceCommand.Flags().StringVarP(&ecsClusterName, "cluster", "c", "", "Cluster Name (required)")
 	ecsRestartServiceCommand.MarkFlagRequired("cluster")
 	ecsRestartServiceCommand.Flags().StringVarP(&ecsServiceName, "service", "s", "", "Service Name")
+	ecsRestartServiceCommand.Flags().StringVar(&ecsSchedulingStrategy, "strategy", "", "Only lists services with this scheduling strategy. Allowed values replica|daemon")
 
 	ecsDescribeCommand.Flags().StringVarP(&ecsClusterName, "cluster", "c", "", "Cluster Name (required)")
-	ecsDescribeCommand.Flags().StringVarP(&ecsServiceName, "service", "s", "", "Filters tasks belonging to the service name provided. Returns the best matching service tasks.")
+	ecsDescribeCommand.Flags().StringVarP(&ecsServiceName, "service", "s", "", "Filters tasks belonging to the services matching the name provided (see --match)")
+	ecsDescribeCommand.Flags().StringVar(&ecsSchedulingStrategy, "strategy", "", "Only describes services with this scheduling strategy. Allowed values replica|daemon")
+
+	ecsRollbackServiceCommand.Flags().StringVarP(&ecsClusterName, "cluster", "c", "", "Cluster Name (required)")
+	ecsRollbackServiceCommand.MarkFlagRequired("cluster")
+	ecsRollbackServiceCommand.Flags().StringVarP(&ecsServiceName, "service", "s", "", "Service Name (required)")
+	ecsRollbackServiceCommand.MarkFlagRequired("service")
+	ecsRollbackServiceCommand.Flags().Int32VarP(&ecsRollbackRevision, "to-revision", "r", 0, "Task definition revision to roll back to. Defaults to the previous revision.")
+	ecsRollbackServiceCommand.Flags().BoolVarP(&ecsRollbackForce, "force", "f", false, "Allows rolling back to an INACTIVE revision")
+
+	ecsTaskDefinitionDiffCommand.Flags().StringVarP(&ecsClusterName, "cluster", "c", "", "Cluster Name")
+	ecsTaskDefinitionDiffCommand.Flags().StringVarP(&ecsServiceName, "service", "s", "", "Service Name")
+
+	ecsExecCommand.Flags().StringVarP(&ecsClusterName, "cluster", "c", "", "Cluster Name (required)")
+	ecsExecCommand.MarkFlagRequired("cluster")
+	ecsExecCommand.Flags().StringVarP(&ecsServiceName, "service", "s", "", "Service Name (required)")
+	ecsExecCommand.MarkFlagRequired("service")
+	ecsExecCommand.Flags().StringVar(&ecsContainerName, "container", "", "Container to exec into. Defaults to the first container with a running exec agent.")
+
+	ecsScaleServiceCommand.Flags().StringVarP(&ecsClusterName, "cluster", "c", "", "Cluster Name (required)")
+	ecsScaleServiceCommand.MarkFlagRequired("cluster")
+	ecsScaleServiceCommand.Flags().StringVarP(&ecsServiceName, "service", "s", "", "Service Name (required)")
+	ecsScaleServiceCommand.MarkFlagRequired("service")
+	ecsScaleServiceCommand.Flags().Int32VarP(&ecsScaleDesired, "desired", "d", 0, "Desired count (required)")
+	ecsScaleServiceCommand.MarkFlagRequired("desired")
+	ecsScaleServiceCommand.Flags().Int32Var(&ecsScaleMin, "min", -1, "Minimum capacity of the autoscaling target")
+	ecsScaleServiceCommand.Flags().Int32Var(&ecsScaleMax, "max", -1, "Maximum capacity of the autoscaling target")
+
+	ecsDrainCommand.Flags().StringVarP(&ecsClusterName, "cluster", "c", "", "Cluster Name (required)")
+	ecsDrainCommand.MarkFlagRequired("cluster")
+	ecsDrainCommand.Flags().StringVarP(&ecsContainerInstance, "instance", "i", "", "Container instance arn/id or ec2 instance id (required)")
+	ecsDrainCommand.MarkFlagRequired("instance")
+	ecsDrainCommand.Flags().BoolVar(&ecsDrainTerminate, "terminate", false, "Terminates the ec2 instance once drained")
+
+	ecsUpdateContainerInstanceCommand.Flags().StringVarP(&ecsClusterName, "cluster", "c", "", "Cluster Name (required)")
+	ecsUpdateContainerInstanceCommand.MarkFlagRequired("cluster")
+	ecsUpdateContainerInstanceCommand.Flags().IntVarP(&ecsAgentUpdateBatch, "batch", "b", 1, "Number of container instances updated at a time")
+	ecsUpdateContainerInstanceCommand.Flags().BoolVarP(&ecsAgentUpdateWait, "wait", "w", false, "Also waits for the last batch to finish updating. Earlier batches are always waited for before starting the next one")
+
+	ecsLogsCommand.Flags().StringVarP(&ecsClusterName, "cluster", "c", "", "Cluster Name (required)")
+	ecsLogsCommand.MarkFlagRequired("cluster")
+	ecsLogsCommand.Flags().StringVarP(&ecsServiceName, "service", "s", "", "Service Name (required)")
+	ecsLogsCommand.MarkFlagRequired("service")
+	ecsLogsCommand.Flags().StringVar(&ecsContainerName, "container", "", "Only prints logs of this container")
+	ecsLogsCommand.Flags().DurationVar(&ecsLogsSince, "since", 10*time.Minute, "Prints logs newer than this duration. Example: 10m, 2h")
+	ecsLogsCommand.Flags().BoolVarP(&ecsLogsFollow, "follow", "f", false, "Keeps polling for new logs")
+	ecsLogsCommand.Flags().StringVarP(&ecsLogsGrep, "grep", "g", "", "Only prints messages matching this regular expression")
+
+	ecsEventsCommand.Flags().StringVarP(&ecsClusterName, "cluster", "c", "", "Cluster Name (required)")
+	ecsEventsCommand.MarkFlagRequired("cluster")
+	ecsEventsCommand.Flags().StringVarP(&ecsServiceName, "service", "s", "", "Service Name (required)")
+	ecsEventsCommand.MarkFlagRequired("service")
+	ecsEventsCommand.Flags().IntVarP(&ecsLimit, "limit", "l", 20, "Number of events to show")
+
+	ecsStoppedTasksCommand.Flags().StringVarP(&ecsClusterName, "cluster", "c", "", "Cluster Name (required)")
+	ecsStoppedTasksCommand.MarkFlagRequired("cluster")
+	ecsStoppedTasksCommand.Flags().StringVarP(&ecsServiceName, "service", "s", "", "Service Name (required)")
+	ecsStoppedTasksCommand.MarkFlagRequired("service")
+	ecsStoppedTasksCommand.Flags().IntVarP(&ecsLimit, "limit", "l", 10, "Number of stopped tasks to show")
+
+	ecsCapacityCommand.Flags().StringVarP(&ecsClusterName, "cluster", "c", "", "Cluster Name (required)")
+	ecsCapacityCommand.MarkFlagRequired("cluster")
+	ecsCapacityCommand.Flags().StringVarP(&ecsTaskDefinition, "task-def", "t", "", "Task definition (family or family:revision) to check placement for")
+	ecsCapacityCommand.Flags().Int32VarP(&ecsCapacityCount, "count", "n", 1, "Number of copies of the task definition to place")
+
+	ecsListClustersCommand.Flags().StringVarP(&ecsClusterName, "cluster", "c", "", "Only lists clusters whose name contains this")
+
+	ecsListServicesCommand.Flags().StringVarP(&ecsClusterName, "cluster", "c", "", "Cluster Name (required)")
+	ecsListServicesCommand.MarkFlagRequired("cluster")
+	ecsListServicesCommand.Flags().StringVar(&ecsSchedulingStrategy, "strategy", "", "Only lists services with this scheduling strategy. Allowed values replica|daemon")
+
+	ecsRunTaskCommand.Flags().StringVarP(&ecsClusterName, "cluster", "c", "", "Cluster Name (required)")
+	ecsRunTaskCommand.MarkFlagRequired("cluster")
+	ecsRunTaskCommand.Flags().StringVarP(&ecsServiceName, "from-service", "s", "", "Service whose configuration the task is launched with (required)")
+	ecsRunTaskCommand.MarkFlagRequired("from-service")
+	ecsRunTaskCommand.Flags().StringVar(&ecsContainerName, "container", "", "Container to override. Defaults to the first essential container.")
+	ecsRunTaskCommand.Flags().StringVar(&ecsRunCommand, "command", "", "Command to run in the container, split like a shell would")
+	ecsRunTaskCommand.Flags().StringArrayVarP(&ecsRunEnvironment, "env", "e", []string{}, "Environment variables to set in the container. Example: KEY=VALUE. Can be used multiple times.")
+	ecsRunTaskCommand.Flags().BoolVarP(&ecsRunWait, "wait", "w", false, "Waits for the task to stop and exits with the container's exit code")
+	ecsRunTaskCommand.Flags().BoolVarP(&ecsRunLogs, "logs", "l", false, "Streams the container's logs until the task stops. Implies --wait.")
+	ecsRunTaskCommand.Flags().DurationVar(&ecsRunTimeout, "timeout", time.Hour, "Maximum time to wait for the task to stop, 0 to wait indefinitely")
+
+	ecsEnvCommand.Flags().StringVarP(&ecsClusterName, "cluster", "c", "", "Cluster Name (required)")
+	ecsEnvCommand.MarkFlagRequired("cluster")
+	ecsEnvCommand.Flags().StringVarP(&ecsServiceName, "service", "s", "", "Service Name (required)")
+	ecsEnvCommand.MarkFlagRequired("service")
+	ecsEnvCommand.Flags().StringVar(&ecsContainerName, "container", "", "Only shows this container")
+	ecsEnvCommand.Flags().BoolVarP(&ecsEnvResolve, "resolve", "r", false, "Fetches the values of referenced secrets")
+	ecsEnvCommand.Flags().BoolVar(&ecsEnvShow, "show", false, "Prints resolved secret values unmasked")
+
+	ecsMetricsCommand.Flags().StringVarP(&ecsClusterName, "cluster", "c", "", "Cluster Name (required)")
+	ecsMetricsCommand.MarkFlagRequired("cluster")
+	ecsMetricsCommand.Flags().StringVarP(&ecsServiceName, "service", "s", "", "Service Name (required)")
+	ecsMetricsCommand.MarkFlagRequired("service")
+	ecsMetricsCommand.Flags().DurationVar(&ecsMetricsPeriod, "period", 5*time.Minute, "Period each datapoint aggregates, a multiple of 1m")
+	ecsMetricsCommand.Flags().DurationVar(&ecsMetricsSince, "since", 3*time.Hour, "How far back to fetch metrics from")
+	ecsMetricsCommand.Flags().StringVarP(&ecsOutput, "output", "o", "table", "Output format. Allowed values table|json")
 }
@@ -1,23 +1,161 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"bitbucket.org/agrim123/onyx/pkg/core/ec2"
 	"bitbucket.org/agrim123/onyx/pkg/core/iam"
 	"bitbucket.org/agrim123/onyx/pkg/logger"
+	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/spf13/cobra"
 )
 
+var (
+	iamResources []string
+	iamProfile   string
+)
+
+var iamCommand = &cobra.Command{
+	Use:   "iam",
+	Short: "Actions to be performed on IAM",
+}
+
+var iamCanCommand = &cobra.Command{
+	Use:     "can <action>... [--resource arn]",
+	Short:   "Checks whether you are allowed to perform actions",
+	Long:    `Simulates the policies of the caller (the role for assumed roles and SSO sessions) for the actions, on the given resources or all resources, and reports the ones which are denied. Exits with 1 if any is denied.`,
+	Args:    cobra.MinimumNArgs(1),
+	Example: "onyx iam can ecs:UpdateService\nonyx iam can ec2:AuthorizeSecurityGroupIngress --resource arn:aws:ec2:us-east-1:123456789012:security-group/sg-0123456789abcdef0",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		allowed, err := iam.Can(ctx, cfg, args, iamResources)
+		if err != nil {
+			return err
+		}
+
+		if !allowed {
+			return exitWithCode(cmd, 1)
+		}
+
+		return nil
+	},
+}
+
+var iamKeysCommand = &cobra.Command{
+	Use:   "keys",
+	Short: "Lists your access keys",
+	Long:  `Lists the access keys of the calling IAM user with their age and when, where and for which service they were last used. Keys older than 90 days are flagged for rotation.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		return iam.PrintAccessKeys(ctx, cfg)
+	},
+}
+
+var iamKeysRotateCommand = &cobra.Command{
+	Use:     "rotate [--profile name]",
+	Short:   "Rotates the access key of a profile",
+	Long:    `Creates a new access key, writes it into the profile of the shared credentials file and verifies it with STS. The old key is then deactivated and deleted. If the new key cannot be verified, it is deleted and the credentials file is restored. The user must have only the key being rotated.`,
+	Args:    cobra.NoArgs,
+	Example: "onyx iam keys rotate\nonyx iam keys rotate --profile staging",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile := iamProfile
+		if profile == "" {
+			profile = os.Getenv("AWS_PROFILE")
+		}
+		if profile == "" {
+			profile = os.Getenv("AWS_DEFAULT_PROFILE")
+		}
+		if profile == "" {
+			profile = "default"
+		}
+
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"), config.WithSharedConfigProfile(profile))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		return iam.RotateAccessKey(ctx, cfg, profile)
+	},
+}
+
+var iamUserCommand = &cobra.Command{
+	Use:     "user <name>",
+	Short:   "Shows the access of a user",
+	Long:    `Prints the groups, policies (direct and through groups), MFA devices, console access, access keys and last activity of an IAM user, followed by the security group rules authorized through onyx by the user. Users without an IAM user, e.g. SSO users, only have their security group rules listed.`,
+	Args:    cobra.ExactArgs(1),
+	Example: "onyx iam user john",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		user, err := iam.GetUser(ctx, cfg, args[0])
+		if err == nil {
+			user.Print()
+		} else if errors.Is(err, iam.ErrUserNotFound) {
+			logger.Warn("No IAM user named %s", logger.Bold(args[0]))
+		} else {
+			return err
+		}
+
+		fmt.Println()
+		return ec2.PrintUserRules(ctx, cfg, args[0])
+	},
+}
+
 var whoamiCmd = &cobra.Command{
 	Use:   "whoami",
 	Short: "Returns the user making requests",
+	Long:  `Prints the account, ARN, principal type and region of the credentials in use. Works for IAM users, assumed roles, SSO sessions and instance profiles. Session names of assumed roles can be mapped to a canonical user in the "users" section of ~/.onyx/config.json.`,
 	Args:  cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		name, err := iam.Whoami()
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		identity, err := iam.GetCallerIdentity(ctx, cfg)
 		if err != nil {
 			return err
 		}
 
-		logger.Info(name)
+		fmt.Println("User:          ", identity.User())
+		fmt.Println("Account:       ", identity.Account)
+		fmt.Println("ARN:           ", identity.Arn)
+		fmt.Println("Principal type:", identity.PrincipalType)
+		if identity.PrincipalType == iam.PrincipalAssumedRole {
+			fmt.Println("Role:          ", identity.Name)
+			fmt.Println("Session:       ", identity.SessionName)
+		}
+		fmt.Println("Region:        ", cfg.Region)
 
 		return nil
 	},
 }
+
+func init() {
+	iamCommand.AddCommand(iamCanCommand, iamKeysCommand, iamUserCommand)
+	iamKeysCommand.AddCommand(iamKeysRotateCommand)
+
+	iamCanCommand.Flags().StringArrayVarP(&iamResources, "resource", "r", []string{}, "Resource arn to check the actions on. Can be used multiple times.")
+	iamKeysRotateCommand.Flags().StringVarP(&iamProfile, "profile", "p", "", "Profile whose key is rotated. Defaults to AWS_PROFILE or default.")
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"bitbucket.org/agrim123/onyx/pkg/core/logs"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/spf13/cobra"
+)
+
+var logsGroups []string
+var logsSince time.Duration
+var logsLimit int32
+var logsOutput string
+var logsQueryName string
+var logsSaveAs string
+
+var logsCommand = &cobra.Command{
+	Use:   "logs",
+	Short: "Actions to be performed on Cloudwatch logs",
+}
+
+var logsQueryCommand = &cobra.Command{
+	Use:   "query --group <log-group> [--since 1h] [--output table|json] '<query>' | --name <saved-query>",
+	Short: "Runs a Logs Insights query",
+	Long: `Starts a Logs Insights query over the log groups, waits for it to complete and renders the results as a table or JSON.
+Queries can be saved with --save and run again by name with --name. Log groups given on the command line take precedence over the saved ones.`,
+	Args:    cobra.MaximumNArgs(1),
+	Example: "onyx logs query --group /ecs/api --since 1h 'fields @timestamp, @message | filter @message like /ERROR/'\nonyx logs query --group /ecs/api 'stats count(*) by bin(5m)' --save errors-per-5m\nonyx logs query --name errors-per-5m --since 6h --output json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		query := ""
+		groups := logsGroups
+
+		if logsQueryName != "" {
+			if len(args) == 1 {
+				return errors.New("provide either a query or --name, not both")
+			}
+
+			savedQuery, err := logs.GetSavedQuery(logsQueryName)
+			if err != nil {
+				return err
+			}
+
+			query = savedQuery.Query
+			if len(groups) == 0 {
+				groups = savedQuery.Groups
+			}
+		} else if len(args) == 1 {
+			query = args[0]
+		} else {
+			return errors.New("provide a query or --name of a saved query")
+		}
+
+		if logsOutput != "table" && logsOutput != "json" {
+			return errors.New("invalid output " + logsOutput + ". Allowed values table|json")
+		}
+
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
+		if err != nil {
+			log.Fatalf("unable to load SDK config, %v", err)
+		}
+		ctx := context.Background()
+
+		end := time.Now()
+		result, err := logs.RunQuery(ctx, cfg, logs.QueryInput{
+			Groups: groups,
+			Query:  query,
+			Start:  end.Add(-logsSince),
+			End:    end,
+			Limit:  logsLimit,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Only queries which ran are saved
+		if logsSaveAs != "" {
+			if err := logs.SaveQuery(logsSaveAs, query, groups); err != nil {
+				return err
+			}
+		}
+
+		if logsOutput == "json" {
+			return result.PrintJSON()
+		}
+
+		result.Print()
+		return nil
+	},
+}
+
+var logsSavedQueriesCommand = &cobra.Command{
+	Use:     "queries",
+	Short:   "Lists saved Logs Insights queries",
+	Args:    cobra.NoArgs,
+	Example: "onyx logs queries",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return logs.PrintSavedQueries()
+	},
+}
+
+func init() {
+	logsCommand.AddCommand(logsQueryCommand, logsSavedQueriesCommand)
+
+	logsQueryCommand.Flags().StringArrayVarP(&logsGroups, "group", "g", []string{}, "Log group to query. Can be used multiple times.")
+	logsQueryCommand.Flags().DurationVar(&logsSince, "since", time.Hour, "How far back to query from")
+	logsQueryCommand.Flags().Int32VarP(&logsLimit, "limit", "l", 0, "Maximum number of rows to return. Defaults to the query's limit or 1000")
+	logsQueryCommand.Flags().StringVarP(&logsOutput, "output", "o", "table", "Output format. Allowed values table|json")
+	logsQueryCommand.Flags().StringVarP(&logsQueryName, "name", "n", "", "Runs the saved query with this name")
+	logsQueryCommand.Flags().StringVar(&logsSaveAs, "save", "", "Saves the query and its log groups under this name")
+}
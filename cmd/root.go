@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -13,11 +15,57 @@ var rootCmd = &cobra.Command{
 }
 
 func init() {
-	rootCmd.AddCommand(ecsCommand, ec2Command, whoamiCmd, cloudwatchCommand, sandstormCommand)
+	rootCmd.AddCommand(ecsCommand, ec2Command, whoamiCmd, cloudwatchCommand, sandstormCommand, logsCommand, iamCommand)
+}
+
+// exitError makes Execute exit with its code once the command has returned, without printing an error
+type exitError struct {
+	code int
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// exitWithCode returns an error making onyx exit with code, so deferred cleanup still runs unlike os.Exit in a command
+func exitWithCode(cmd *cobra.Command, code int) error {
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	return &exitError{code: code}
+}
+
+// skipsAlarmUnmute reports whether the invocation only prints help or completions, which should not call AWS
+func skipsAlarmUnmute(args []string) bool {
+	if len(args) == 0 {
+		return true
+	}
+
+	switch args[0] {
+	case "help", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return true
+	}
+
+	for _, arg := range args {
+		if arg == "-h" || arg == "--help" {
+			return true
+		}
+	}
+
+	return false
 }
 
 func Execute() {
+	// Done before dispatch as cobra only runs the nearest persistent pre-run of a command
+	if !skipsAlarmUnmute(os.Args[1:]) {
+		unmuteExpiredAlarms()
+	}
+
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
+
 		os.Exit(1)
 	}
 }
@@ -5,18 +5,25 @@ import (
 	"errors"
 	"log"
 
+	"bitbucket.org/agrim123/onyx/pkg/core/iam"
 	"bitbucket.org/agrim123/onyx/pkg/core/sandstorm"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/spf13/cobra"
 )
 
+// Sandstorm scales entire environments and stays off until its service lists are filled in
+const sandstormEnabled = false
+
 var sandstormCommand = &cobra.Command{
 	Use:     "sandstorm <env> <init|revert>",
 	Short:   "Starts or stops entire ecs infra",
-	Args:    cobra.MinimumNArgs(1),
-	Example: "onyx sandstorm init\nonyx sandstorm revert",
+	Args:    cobra.ExactArgs(2),
+	Example: "onyx sandstorm staging init\nonyx sandstorm staging revert",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return errors.New("Disabled")
+		if !sandstormEnabled {
+			return errors.New("Disabled")
+		}
+
 		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
 		if err != nil {
 			log.Fatalf("unable to load SDK config, %v", err)
@@ -30,6 +37,11 @@ var sandstormCommand = &cobra.Command{
 			return errors.New("Invalid type: " + args[1])
 		}
 
+		// Application autoscaling does not support resource level permissions
+		if err := iam.Preflight(ctx, cfg, []string{"ecs:UpdateService", "application-autoscaling:RegisterScalableTarget"}, nil); err != nil {
+			return err
+		}
+
 		sandstorm.Process(ctx, cfg, args[0], args[1])
 
 		return nil
@@ -5,11 +5,17 @@ go 1.16
 require (
 	github.com/aws/aws-sdk-go-v2 v1.4.0
 	github.com/aws/aws-sdk-go-v2/config v1.1.6
+	github.com/aws/aws-sdk-go-v2/credentials v1.1.6
 	github.com/aws/aws-sdk-go-v2/service/applicationautoscaling v1.2.3
+	github.com/aws/aws-sdk-go-v2/service/cloudwatch v1.3.1
 	github.com/aws/aws-sdk-go-v2/service/cloudwatchevents v1.3.2
+	github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs v1.2.3
 	github.com/aws/aws-sdk-go-v2/service/ec2 v1.5.0
 	github.com/aws/aws-sdk-go-v2/service/ecs v1.2.2
 	github.com/aws/aws-sdk-go-v2/service/iam v1.3.1
+	github.com/aws/aws-sdk-go-v2/service/secretsmanager v1.2.3
+	github.com/aws/aws-sdk-go-v2/service/ssm v1.5.0
+	github.com/aws/aws-sdk-go-v2/service/sts v1.3.0
 	github.com/fatih/color v1.10.0
 	github.com/spf13/cobra v1.1.3
 )
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const (
+	dirName    = ".onyx"
+	configFile = "config.json"
+)
+
+// SavedQuery is a named Logs Insights query
+type SavedQuery struct {
+	Query  string   `json:"query"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// Config is the onyx configuration kept in ~/.onyx/config.json
+type Config struct {
+	Queries map[string]SavedQuery `json:"queries,omitempty"`
+	// Users maps session names of assumed roles, e.g. SSO emails, to the canonical user name
+	Users map[string]string `json:"users,omitempty"`
+}
+
+// Dir returns the directory onyx keeps its local state in, ~/.onyx. It may not exist yet.
+func Dir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, dirName), nil
+}
+
+// EnsureDir returns the onyx directory, creating it if needed. Only to be used before writing to it.
+func EnsureDir() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	return dir, nil
+}
+
+func path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, configFile), nil
+}
+
+// Load reads the onyx configuration, an empty one if the file does not exist yet
+func Load() (*Config, error) {
+	configPath, err := path()
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+
+	data, err := ioutil.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, errors.New("invalid " + configPath + ". Error: " + err.Error())
+	}
+
+	return config, nil
+}
+
+// Save writes the onyx configuration
+func (c *Config) Save() error {
+	if _, err := EnsureDir(); err != nil {
+		return err
+	}
+
+	configPath, err := path()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(configPath, data, 0600)
+}
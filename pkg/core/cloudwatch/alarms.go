@@ -0,0 +1,383 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"bitbucket.org/agrim123/onyx/pkg/config"
+	"bitbucket.org/agrim123/onyx/pkg/logger"
+	"bitbucket.org/agrim123/onyx/pkg/utils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	metricsLib "github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	metricsTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+const alarmMutesFile = "alarm-mutes.json"
+
+// Alarm is a cloudwatch metric alarm
+type Alarm struct {
+	Name           string
+	State          metricsTypes.StateValue
+	StateReason    string
+	Metric         string
+	Dimensions     map[string]string
+	ActionsEnabled bool
+	UpdatedAt      *time.Time
+}
+
+// AlarmMute records an alarm whose actions onyx disabled and when they are to be re-enabled
+type AlarmMute struct {
+	Name  string    `json:"name"`
+	Until time.Time `json:"until"`
+}
+
+// ListAlarms returns the metric alarms whose name starts with prefix and which have all of the
+// `name=value` dimensions. If states are given, only alarms in one of them are returned.
+func ListAlarms(ctx context.Context, cfg aws.Config, prefix string, dimensions []string, states []metricsTypes.StateValue) ([]Alarm, error) {
+	dimensionFilters, err := parseKeyValues("dimension", dimensions)
+	if err != nil {
+		return nil, err
+	}
+
+	metricsHandler := metricsLib.NewFromConfig(cfg)
+
+	alarms := make([]Alarm, 0)
+
+	var nextToken *string
+	for {
+		input := &metricsLib.DescribeAlarmsInput{
+			NextToken: nextToken,
+		}
+		if prefix != "" {
+			input.AlarmNamePrefix = aws.String(prefix)
+		}
+
+		output, err := metricsHandler.DescribeAlarms(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, metricAlarm := range output.MetricAlarms {
+			alarm := Alarm{
+				Name:           aws.ToString(metricAlarm.AlarmName),
+				State:          metricAlarm.StateValue,
+				StateReason:    aws.ToString(metricAlarm.StateReason),
+				Metric:         aws.ToString(metricAlarm.Namespace) + "/" + aws.ToString(metricAlarm.MetricName),
+				Dimensions:     make(map[string]string),
+				ActionsEnabled: aws.ToBool(metricAlarm.ActionsEnabled),
+				UpdatedAt:      metricAlarm.StateUpdatedTimestamp,
+			}
+
+			// Alarms on metric math expressions have no single metric
+			if metricAlarm.MetricName == nil {
+				alarm.Metric = "(expression)"
+			}
+
+			for _, dimension := range metricAlarm.Dimensions {
+				alarm.Dimensions[aws.ToString(dimension.Name)] = aws.ToString(dimension.Value)
+			}
+
+			if alarm.hasDimensions(dimensionFilters) && alarm.inStates(states) {
+				alarms = append(alarms, alarm)
+			}
+		}
+
+		if output.NextToken == nil {
+			break
+		}
+
+		nextToken = output.NextToken
+	}
+
+	return alarms, nil
+}
+
+func (a *Alarm) hasDimensions(dimensions map[string]string) bool {
+	for name, value := range dimensions {
+		if alarmValue, ok := a.Dimensions[name]; !ok || alarmValue != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (a *Alarm) inStates(states []metricsTypes.StateValue) bool {
+	if len(states) == 0 {
+		return true
+	}
+
+	for _, state := range states {
+		if a.State == state {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PrintAlarms lists the alarms matching the prefix and dimensions which are in ALARM or INSUFFICIENT_DATA state,
+// or in any state if all is set
+func PrintAlarms(ctx context.Context, cfg aws.Config, prefix string, dimensions []string, all bool) error {
+	states := []metricsTypes.StateValue{metricsTypes.StateValueAlarm, metricsTypes.StateValueInsufficientData}
+	if all {
+		states = nil
+	}
+
+	alarms, err := ListAlarms(ctx, cfg, prefix, dimensions, states)
+	if err != nil {
+		return err
+	}
+
+	if len(alarms) == 0 {
+		logger.Success("No alarms firing")
+		return nil
+	}
+
+	mutes, err := loadAlarmMutes()
+	if err != nil {
+		return err
+	}
+
+	mutedUntil := make(map[string]time.Time)
+	for _, mute := range mutes {
+		mutedUntil[mute.Name] = mute.Until
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ALARM\tSTATE\tSINCE\tMETRIC\tACTIONS")
+	for _, alarm := range alarms {
+		since := "-"
+		if alarm.UpdatedAt != nil {
+			since = utils.HumanizeDuration(time.Since(*alarm.UpdatedAt)) + " ago"
+		}
+
+		actions := "enabled"
+		if until, ok := mutedUntil[alarm.Name]; ok {
+			actions = "muted until " + until.Local().Format("Mon 15:04")
+		} else if !alarm.ActionsEnabled {
+			actions = "disabled"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", alarm.Name, alarm.State, since, alarm.Metric, actions)
+	}
+	w.Flush()
+
+	return nil
+}
+
+func alarmMutesPath() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, alarmMutesFile), nil
+}
+
+func loadAlarmMutes() ([]AlarmMute, error) {
+	path, err := alarmMutesPath()
+	if err != nil {
+		return nil, err
+	}
+
+	mutes := make([]AlarmMute, 0)
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return mutes, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &mutes); err != nil {
+		return nil, errors.New("invalid " + path + ". Error: " + err.Error())
+	}
+
+	return mutes, nil
+}
+
+func saveAlarmMutes(mutes []AlarmMute) error {
+	if _, err := config.EnsureDir(); err != nil {
+		return err
+	}
+
+	path, err := alarmMutesPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(mutes, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// MuteAlarms disables the actions of the alarms matching the prefix and dimensions for the given duration.
+// Alarms whose actions were already disabled outside of onyx are left alone so unmuting does not enable them.
+func MuteAlarms(ctx context.Context, cfg aws.Config, prefix string, dimensions []string, duration time.Duration) error {
+	// Without filters every alarm in the account would be muted
+	if prefix == "" && len(dimensions) == 0 {
+		return errors.New("a prefix or dimension is required to match alarms")
+	}
+
+	if duration <= 0 {
+		return errors.New("mute duration must be positive")
+	}
+
+	alarms, err := ListAlarms(ctx, cfg, prefix, dimensions, nil)
+	if err != nil {
+		return err
+	}
+
+	if len(alarms) == 0 {
+		return errors.New("no alarms matched")
+	}
+
+	mutes, err := loadAlarmMutes()
+	if err != nil {
+		return err
+	}
+
+	muted := make(map[string]int)
+	for i, mute := range mutes {
+		muted[mute.Name] = i
+	}
+
+	until := time.Now().Add(duration)
+
+	toDisable := make([]string, 0)
+	extended := 0
+	for _, alarm := range alarms {
+		// Already muted alarms get their window moved
+		if i, ok := muted[alarm.Name]; ok {
+			mutes[i].Until = until
+			extended++
+			continue
+		}
+
+		if !alarm.ActionsEnabled {
+			logger.Warn("Skipping %s, its actions are already disabled", alarm.Name)
+			continue
+		}
+
+		toDisable = append(toDisable, alarm.Name)
+	}
+
+	metricsHandler := metricsLib.NewFromConfig(cfg)
+	for _, chunk := range utils.GetChunks(toDisable, 100) {
+		_, err := metricsHandler.DisableAlarmActions(ctx, &metricsLib.DisableAlarmActionsInput{
+			AlarmNames: chunk,
+		})
+		if err != nil {
+			// Keep the record of the chunks already muted so they can be unmuted
+			if saveErr := saveAlarmMutes(mutes); saveErr != nil {
+				logger.Error("Some alarm actions were disabled but unable to record the mute. Error: %s", saveErr.Error())
+			}
+			return err
+		}
+
+		for _, name := range chunk {
+			mutes = append(mutes, AlarmMute{Name: name, Until: until})
+		}
+	}
+
+	if err := saveAlarmMutes(mutes); err != nil {
+		return errors.New("alarm actions disabled but unable to record the mute. Error: " + err.Error())
+	}
+
+	for _, name := range toDisable {
+		fmt.Println("  ", name)
+	}
+	logger.Success("Muted %d alarm(s) until %s", len(toDisable), until.Local().Format("Mon, 02 Jan 2006 15:04 MST"))
+	if extended > 0 {
+		logger.Info("Extended the mute of %d already muted alarm(s)", extended)
+	}
+
+	return nil
+}
+
+// UnmuteAlarms re-enables the actions of alarms muted by onyx whose name starts with prefix
+func UnmuteAlarms(ctx context.Context, cfg aws.Config, prefix string) error {
+	return unmuteAlarms(ctx, cfg, func(mute AlarmMute) bool {
+		return strings.HasPrefix(mute.Name, prefix)
+	})
+}
+
+// UnmuteExpiredAlarms re-enables the actions of alarms whose mute window has passed
+func UnmuteExpiredAlarms(ctx context.Context, cfg aws.Config) error {
+	now := time.Now()
+	return unmuteAlarms(ctx, cfg, func(mute AlarmMute) bool {
+		return mute.Until.Before(now)
+	})
+}
+
+// HasExpiredAlarmMutes reports whether any mute window has passed, without calling AWS
+func HasExpiredAlarmMutes() bool {
+	mutes, err := loadAlarmMutes()
+	if err != nil {
+		return false
+	}
+
+	for _, mute := range mutes {
+		if mute.Until.Before(time.Now()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func unmuteAlarms(ctx context.Context, cfg aws.Config, matches func(AlarmMute) bool) error {
+	mutes, err := loadAlarmMutes()
+	if err != nil {
+		return err
+	}
+
+	toEnable := make([]string, 0)
+	remaining := make([]AlarmMute, 0)
+	for _, mute := range mutes {
+		if matches(mute) {
+			toEnable = append(toEnable, mute.Name)
+		} else {
+			remaining = append(remaining, mute)
+		}
+	}
+
+	if len(toEnable) == 0 {
+		logger.Info("No muted alarms matched")
+		return nil
+	}
+
+	metricsHandler := metricsLib.NewFromConfig(cfg)
+	for _, chunk := range utils.GetChunks(toEnable, 100) {
+		_, err := metricsHandler.EnableAlarmActions(ctx, &metricsLib.EnableAlarmActionsInput{
+			AlarmNames: chunk,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := saveAlarmMutes(remaining); err != nil {
+		return err
+	}
+
+	for _, name := range toEnable {
+		fmt.Println("  ", name)
+	}
+	logger.Success("Unmuted %d alarm(s)", len(toEnable))
+
+	return nil
+}
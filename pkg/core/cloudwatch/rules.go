@@ -0,0 +1,315 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"bitbucket.org/agrim123/onyx/pkg/logger"
+	"bitbucket.org/agrim123/onyx/pkg/utils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchLib "github.com/aws/aws-sdk-go-v2/service/cloudwatchevents"
+	cloudwatchTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchevents/types"
+	ecsLib "github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecsTypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+// Rule is a cloudwatch events rule along with its targets
+type Rule struct {
+	Name               string
+	Arn                string
+	Description        string
+	ScheduleExpression string
+	State              cloudwatchTypes.RuleState
+	Targets            []cloudwatchTypes.Target
+}
+
+// HasClusterTarget reports whether any ECS target of the rule runs tasks in the cluster
+func (r *Rule) HasClusterTarget(clusterName string) bool {
+	for _, target := range r.Targets {
+		if target.EcsParameters != nil && targetClusterName(target) == clusterName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// targetClusterName extracts the cluster name from the arn of an ECS target
+func targetClusterName(target cloudwatchTypes.Target) string {
+	a := strings.Split(aws.ToString(target.Arn), "/")
+	return a[len(a)-1]
+}
+
+func targetToString(target cloudwatchTypes.Target) string {
+	if target.EcsParameters == nil {
+		return aws.ToString(target.Arn)
+	}
+
+	a := strings.Split(aws.ToString(target.EcsParameters.TaskDefinitionArn), "/")
+	return fmt.Sprintf("ecs: %s on %s (count: %d)", a[len(a)-1], targetClusterName(target), aws.ToInt32(target.EcsParameters.TaskCount))
+}
+
+// ListRules returns the rules whose name starts with prefix, along with their targets
+func ListRules(ctx context.Context, cfg aws.Config, prefix string) ([]Rule, error) {
+	rules, err := listRules(ctx, cfg, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range rules {
+		targets, err := listTargets(ctx, cfg, rules[i].Name)
+		if err != nil {
+			return nil, err
+		}
+
+		rules[i].Targets = targets
+	}
+
+	return rules, nil
+}
+
+// listRules returns the rules whose name starts with prefix, without their targets
+func listRules(ctx context.Context, cfg aws.Config, prefix string) ([]Rule, error) {
+	cloudwatchHandler := cloudwatchLib.NewFromConfig(cfg)
+
+	rules := make([]Rule, 0)
+
+	var nextToken *string
+	for {
+		input := &cloudwatchLib.ListRulesInput{
+			NextToken: nextToken,
+		}
+		if prefix != "" {
+			input.NamePrefix = aws.String(prefix)
+		}
+
+		output, err := cloudwatchHandler.ListRules(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rule := range output.Rules {
+			rules = append(rules, Rule{
+				Name:               aws.ToString(rule.Name),
+				Arn:                aws.ToString(rule.Arn),
+				Description:        aws.ToString(rule.Description),
+				ScheduleExpression: aws.ToString(rule.ScheduleExpression),
+				State:              rule.State,
+			})
+		}
+
+		if output.NextToken == nil {
+			break
+		}
+
+		nextToken = output.NextToken
+	}
+
+	return rules, nil
+}
+
+func listTargets(ctx context.Context, cfg aws.Config, ruleName string) ([]cloudwatchTypes.Target, error) {
+	cloudwatchHandler := cloudwatchLib.NewFromConfig(cfg)
+
+	targets := make([]cloudwatchTypes.Target, 0)
+
+	var nextToken *string
+	for {
+		output, err := cloudwatchHandler.ListTargetsByRule(ctx, &cloudwatchLib.ListTargetsByRuleInput{
+			Rule:      aws.String(ruleName),
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		targets = append(targets, output.Targets...)
+
+		if output.NextToken == nil {
+			break
+		}
+
+		nextToken = output.NextToken
+	}
+
+	return targets, nil
+}
+
+// PrintRules lists the rules matching the prefix with their schedule, state and targets.
+// If clusterName is given, only rules having an ECS target in that cluster are listed.
+func PrintRules(ctx context.Context, cfg aws.Config, prefix, clusterName string) error {
+	rules, err := ListRules(ctx, cfg, prefix)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RULE\tSTATE\tSCHEDULE\tTARGETS")
+	for _, rule := range rules {
+		if clusterName != "" && !rule.HasClusterTarget(clusterName) {
+			continue
+		}
+
+		schedule := rule.ScheduleExpression
+		if schedule == "" {
+			schedule = "(event pattern)"
+		}
+
+		if len(rule.Targets) == 0 {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", rule.Name, rule.State, schedule, "-")
+			continue
+		}
+
+		for i, target := range rule.Targets {
+			if i == 0 {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", rule.Name, rule.State, schedule, targetToString(target))
+			} else {
+				fmt.Fprintf(w, "\t\t\t%s\n", targetToString(target))
+			}
+		}
+	}
+	w.Flush()
+
+	return nil
+}
+
+// RunRuleNow launches the ECS targets of the rule immediately with the same task definition,
+// network configuration, launch type and container overrides the rule would use.
+func RunRuleNow(ctx context.Context, cfg aws.Config, name string) error {
+	targets, err := listTargets(ctx, cfg, name)
+	if err != nil {
+		return err
+	}
+
+	ecsHandler := ecsLib.NewFromConfig(cfg)
+
+	launched := 0
+	for _, target := range targets {
+		if target.EcsParameters == nil {
+			logger.Warn("Skipping target %s, only ECS targets can be run", aws.ToString(target.Id))
+			continue
+		}
+
+		input, err := runTaskInputFromTarget(target)
+		if err != nil {
+			return fmt.Errorf("unable to build task for target %s. Error: %s", aws.ToString(target.Id), err.Error())
+		}
+
+		output, err := ecsHandler.RunTask(ctx, input)
+		if err != nil {
+			return err
+		}
+
+		if len(output.Failures) > 0 {
+			return fmt.Errorf("unable to run target %s. Reason: %s", aws.ToString(target.Id), aws.ToString(output.Failures[0].Reason))
+		}
+
+		for _, task := range output.Tasks {
+			a := strings.Split(aws.ToString(task.TaskArn), "/")
+			logger.Success("Started task %s (%s) in %s", logger.Bold(a[len(a)-1]), aws.ToString(task.TaskDefinitionArn), targetClusterName(target))
+		}
+
+		launched++
+	}
+
+	if launched == 0 {
+		return errors.New("rule " + name + " has no ECS targets")
+	}
+
+	return nil
+}
+
+// runTaskInputFromTarget converts the ECS parameters and constant input of a rule target into a RunTask request
+func runTaskInputFromTarget(target cloudwatchTypes.Target) (*ecsLib.RunTaskInput, error) {
+	parameters := target.EcsParameters
+
+	input := &ecsLib.RunTaskInput{
+		Cluster:         target.Arn,
+		TaskDefinition:  parameters.TaskDefinitionArn,
+		Count:           aws.Int32(1),
+		Group:           parameters.Group,
+		LaunchType:      ecsTypes.LaunchType(parameters.LaunchType),
+		PlatformVersion: parameters.PlatformVersion,
+		StartedBy:       aws.String("onyx"),
+	}
+
+	if parameters.TaskCount != nil {
+		input.Count = parameters.TaskCount
+	}
+
+	if parameters.NetworkConfiguration != nil && parameters.NetworkConfiguration.AwsvpcConfiguration != nil {
+		vpcConfiguration := parameters.NetworkConfiguration.AwsvpcConfiguration
+		input.NetworkConfiguration = &ecsTypes.NetworkConfiguration{
+			AwsvpcConfiguration: &ecsTypes.AwsVpcConfiguration{
+				Subnets:        vpcConfiguration.Subnets,
+				SecurityGroups: vpcConfiguration.SecurityGroups,
+				AssignPublicIp: ecsTypes.AssignPublicIp(vpcConfiguration.AssignPublicIp),
+			},
+		}
+	}
+
+	// For ECS targets, a constant input is the task override, e.g. {"containerOverrides": [{"name": "app", "command": ["..."]}]}
+	if target.Input != nil {
+		overrides := &ecsTypes.TaskOverride{}
+		if err := json.Unmarshal([]byte(aws.ToString(target.Input)), overrides); err != nil {
+			return nil, errors.New("invalid target input: " + err.Error())
+		}
+
+		input.Overrides = overrides
+	}
+
+	if target.InputPath != nil || target.InputTransformer != nil {
+		logger.Warn("Target %s derives its input from the triggering event, running without overrides", aws.ToString(target.Id))
+	}
+
+	return input, nil
+}
+
+// PrintNextFireTimes prints the upcoming fire times of a rule, or of a cron(...) or rate(...) expression, in the location
+func PrintNextFireTimes(ctx context.Context, cfg aws.Config, ruleOrExpression string, count int, location *time.Location) error {
+	expression := ruleOrExpression
+	if !strings.HasPrefix(expression, "cron(") && !strings.HasPrefix(expression, "rate(") {
+		cloudwatchHandler := cloudwatchLib.NewFromConfig(cfg)
+		output, err := cloudwatchHandler.DescribeRule(ctx, &cloudwatchLib.DescribeRuleInput{
+			Name: aws.String(ruleOrExpression),
+		})
+		if err != nil {
+			return err
+		}
+
+		if output.ScheduleExpression == nil {
+			return errors.New("rule " + ruleOrExpression + " is triggered by an event pattern, not a schedule")
+		}
+
+		expression = aws.ToString(output.ScheduleExpression)
+		logger.Info("%s (%s): %s", logger.Bold(ruleOrExpression), output.State, expression)
+	}
+
+	schedule, err := ParseSchedule(expression)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := schedule.(*RateSchedule); ok {
+		logger.Warn("Rates are counted from when the rule was created, times below are counted from now")
+	}
+
+	now := time.Now()
+	times := NextFireTimes(schedule, now, count)
+	if len(times) == 0 {
+		logger.Warn("%s never fires again", expression)
+		return nil
+	}
+
+	for _, t := range times {
+		fmt.Printf("  %s    (in %s)\n", t.In(location).Format("Mon, 02 Jan 2006 15:04 MST"), utils.HumanizeDuration(t.Sub(now)))
+	}
+
+	return nil
+}
@@ -0,0 +1,436 @@
+package cloudwatch
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	cronMinYear = 1970
+	cronMaxYear = 2199
+)
+
+var monthNames = map[string]int{
+	"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+	"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+}
+
+// Day of week names as numbered by AWS, 1 is Sunday
+var weekdayNames = map[string]int{
+	"SUN": 1, "MON": 2, "TUE": 3, "WED": 4, "THU": 5, "FRI": 6, "SAT": 7,
+}
+
+// Schedule computes fire times of a schedule expression. All times are in UTC, as evaluated by AWS.
+type Schedule interface {
+	// Next returns the first fire time strictly after t, false if there is none
+	Next(t time.Time) (time.Time, bool)
+}
+
+// RateSchedule is a `rate(value unit)` expression. AWS counts rates from when the rule was created,
+// which is not known locally, so fire times are counted from the given time.
+type RateSchedule struct {
+	Interval time.Duration
+}
+
+func (r *RateSchedule) Next(t time.Time) (time.Time, bool) {
+	return t.UTC().Truncate(time.Minute).Add(r.Interval), true
+}
+
+// nthWeekday is a `weekday#n` day of week, the nth occurrence of the weekday in the month
+type nthWeekday struct {
+	weekday int
+	n       int
+}
+
+// CronSchedule is an AWS six field `cron(minutes hours day-of-month month day-of-week year)` expression
+type CronSchedule struct {
+	minutes []bool
+	hours   []bool
+	months  []bool
+	years   []bool
+
+	// Exactly one of day of month and day of week is `?`
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+
+	daysOfMonth        []bool
+	lastDayOfMonth     bool
+	lastWeekdayOfMonth bool
+	nearestWeekdays    []int
+
+	daysOfWeek     []bool
+	lastDaysOfWeek []int
+	nthDaysOfWeek  []nthWeekday
+}
+
+// ParseSchedule parses an AWS `cron(...)` or `rate(...)` schedule expression
+func ParseSchedule(expression string) (Schedule, error) {
+	expression = strings.TrimSpace(expression)
+
+	switch {
+	case strings.HasPrefix(expression, "rate(") && strings.HasSuffix(expression, ")"):
+		return parseRate(strings.TrimSuffix(strings.TrimPrefix(expression, "rate("), ")"))
+	case strings.HasPrefix(expression, "cron(") && strings.HasSuffix(expression, ")"):
+		return parseCron(strings.TrimSuffix(strings.TrimPrefix(expression, "cron("), ")"))
+	}
+
+	return nil, errors.New("invalid schedule expression " + expression + ". Expected cron(...) or rate(...)")
+}
+
+// ValidateSchedule reports why a schedule expression would be rejected, nil if it is valid
+func ValidateSchedule(expression string) error {
+	_, err := ParseSchedule(expression)
+	return err
+}
+
+// NextFireTimes returns up to count fire times of the schedule after t
+func NextFireTimes(schedule Schedule, t time.Time, count int) []time.Time {
+	times := make([]time.Time, 0)
+	for len(times) < count {
+		next, ok := schedule.Next(t)
+		if !ok {
+			break
+		}
+
+		times = append(times, next)
+		t = next
+	}
+
+	return times
+}
+
+func parseRate(rate string) (*RateSchedule, error) {
+	parts := strings.Fields(rate)
+	if len(parts) != 2 {
+		return nil, errors.New("invalid rate " + rate + ". Expected rate(value unit), e.g. rate(5 minutes)")
+	}
+
+	value, err := strconv.Atoi(parts[0])
+	if err != nil || value <= 0 {
+		return nil, errors.New("invalid rate value " + parts[0] + ". Expected a positive integer")
+	}
+
+	units := map[string]time.Duration{
+		"minute": time.Minute,
+		"hour":   time.Hour,
+		"day":    24 * time.Hour,
+	}
+
+	unit := parts[1]
+	singular := strings.TrimSuffix(unit, "s")
+	duration, ok := units[singular]
+	if !ok {
+		return nil, errors.New("invalid rate unit " + unit + ". Expected minute(s), hour(s) or day(s)")
+	}
+
+	// AWS rejects `rate(1 minutes)` and `rate(5 minute)`
+	if value == 1 && unit != singular {
+		return nil, fmt.Errorf("invalid rate unit %s. Use %s for a value of 1", unit, singular)
+	}
+	if value > 1 && unit == singular {
+		return nil, fmt.Errorf("invalid rate unit %s. Use %ss for a value greater than 1", unit, singular)
+	}
+
+	return &RateSchedule{Interval: time.Duration(value) * duration}, nil
+}
+
+func parseCron(cron string) (*CronSchedule, error) {
+	fields := strings.Fields(cron)
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("invalid cron %s. Expected 6 fields (minutes hours day-of-month month day-of-week year), got %d", cron, len(fields))
+	}
+
+	schedule := &CronSchedule{}
+
+	var err error
+	if schedule.minutes, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, errors.New("invalid minutes: " + err.Error())
+	}
+
+	if schedule.hours, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, errors.New("invalid hours: " + err.Error())
+	}
+
+	if schedule.months, err = parseCronField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, errors.New("invalid month: " + err.Error())
+	}
+
+	if schedule.years, err = parseCronField(fields[5], cronMinYear, cronMaxYear, nil); err != nil {
+		return nil, errors.New("invalid year: " + err.Error())
+	}
+
+	schedule.anyDayOfMonth = fields[2] == "?"
+	schedule.anyDayOfWeek = fields[4] == "?"
+	if schedule.anyDayOfMonth == schedule.anyDayOfWeek {
+		return nil, errors.New("exactly one of day-of-month and day-of-week must be ?")
+	}
+
+	if !schedule.anyDayOfMonth {
+		if err := schedule.parseDaysOfMonth(fields[2]); err != nil {
+			return nil, errors.New("invalid day-of-month: " + err.Error())
+		}
+	}
+
+	if !schedule.anyDayOfWeek {
+		if err := schedule.parseDaysOfWeek(fields[4]); err != nil {
+			return nil, errors.New("invalid day-of-week: " + err.Error())
+		}
+	}
+
+	return schedule, nil
+}
+
+func (c *CronSchedule) parseDaysOfMonth(field string) error {
+	c.daysOfMonth = make([]bool, 32)
+
+	for _, item := range strings.Split(field, ",") {
+		switch {
+		case item == "L":
+			c.lastDayOfMonth = true
+		case item == "LW":
+			c.lastWeekdayOfMonth = true
+		case strings.HasSuffix(item, "W"):
+			day, err := parseCronValue(strings.TrimSuffix(item, "W"), 1, 31, nil)
+			if err != nil {
+				return err
+			}
+			c.nearestWeekdays = append(c.nearestWeekdays, day)
+		default:
+			values, err := parseCronField(item, 1, 31, nil)
+			if err != nil {
+				return err
+			}
+			for day, ok := range values {
+				c.daysOfMonth[day] = c.daysOfMonth[day] || ok
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *CronSchedule) parseDaysOfWeek(field string) error {
+	c.daysOfWeek = make([]bool, 8)
+
+	for _, item := range strings.Split(field, ",") {
+		switch {
+		case item == "L":
+			// On its own, L is the last day of the week
+			c.daysOfWeek[7] = true
+		case strings.HasSuffix(item, "L"):
+			weekday, err := parseCronValue(strings.TrimSuffix(item, "L"), 1, 7, weekdayNames)
+			if err != nil {
+				return err
+			}
+			c.lastDaysOfWeek = append(c.lastDaysOfWeek, weekday)
+		case strings.Contains(item, "#"):
+			parts := strings.SplitN(item, "#", 2)
+			weekday, err := parseCronValue(parts[0], 1, 7, weekdayNames)
+			if err != nil {
+				return err
+			}
+			n, err := parseCronValue(parts[1], 1, 5, nil)
+			if err != nil {
+				return err
+			}
+			c.nthDaysOfWeek = append(c.nthDaysOfWeek, nthWeekday{weekday: weekday, n: n})
+		default:
+			values, err := parseCronField(item, 1, 7, weekdayNames)
+			if err != nil {
+				return err
+			}
+			for weekday, ok := range values {
+				c.daysOfWeek[weekday] = c.daysOfWeek[weekday] || ok
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseCronField parses a comma separated list of `*`, values, ranges `a-b` and increments `a/n`, `*/n`, `a-b/n`
+// into a slice indexed by value
+func parseCronField(field string, min, max int, names map[string]int) ([]bool, error) {
+	values := make([]bool, max+1)
+
+	for _, item := range strings.Split(field, ",") {
+		step, hasStep := 1, false
+		if i := strings.Index(item, "/"); i >= 0 {
+			s, err := strconv.Atoi(item[i+1:])
+			if err != nil || s <= 0 {
+				return nil, errors.New("invalid increment in " + item)
+			}
+			step, hasStep = s, true
+			item = item[:i]
+		}
+
+		start, end := min, max
+		switch {
+		case item == "*":
+		case strings.Contains(item, "-"):
+			parts := strings.SplitN(item, "-", 2)
+
+			var err error
+			if start, err = parseCronValue(parts[0], min, max, names); err != nil {
+				return nil, err
+			}
+			if end, err = parseCronValue(parts[1], min, max, names); err != nil {
+				return nil, err
+			}
+			if start > end {
+				return nil, fmt.Errorf("invalid range %s", item)
+			}
+		default:
+			value, err := parseCronValue(item, min, max, names)
+			if err != nil {
+				return nil, err
+			}
+
+			start = value
+			// `a/n` runs from a to the end of the range, a single value is just a
+			if !hasStep {
+				end = value
+			}
+		}
+
+		for value := start; value <= end; value += step {
+			values[value] = true
+		}
+	}
+
+	return values, nil
+}
+
+func parseCronValue(value string, min, max int, names map[string]int) (int, error) {
+	if n, ok := names[strings.ToUpper(value)]; ok {
+		return n, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.New("invalid value " + value)
+	}
+
+	if n < min || n > max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", n, min, max)
+	}
+
+	return n, nil
+}
+
+// Next returns the first minute after t matching the expression
+func (c *CronSchedule) Next(t time.Time) (time.Time, bool) {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	for day.Year() <= cronMaxYear {
+		if day.Year() < cronMinYear || !c.years[day.Year()] {
+			day = time.Date(day.Year()+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+
+		if !c.months[int(day.Month())] {
+			day = time.Date(day.Year(), day.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+
+		if !c.matchesDay(day) {
+			day = day.AddDate(0, 0, 1)
+			continue
+		}
+
+		startHour, startMinute := 0, 0
+		if day.Equal(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)) {
+			startHour, startMinute = t.Hour(), t.Minute()
+		}
+
+		for hour := startHour; hour < 24; hour++ {
+			if !c.hours[hour] {
+				continue
+			}
+
+			minute := 0
+			if hour == startHour {
+				minute = startMinute
+			}
+
+			for ; minute < 60; minute++ {
+				if c.minutes[minute] {
+					return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, time.UTC), true
+				}
+			}
+		}
+
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return time.Time{}, false
+}
+
+func (c *CronSchedule) matchesDay(day time.Time) bool {
+	lastDay := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	if c.anyDayOfWeek {
+		if c.daysOfMonth[day.Day()] {
+			return true
+		}
+
+		if c.lastDayOfMonth && day.Day() == lastDay {
+			return true
+		}
+
+		if c.lastWeekdayOfMonth && day.Day() == nearestWeekday(day, lastDay) {
+			return true
+		}
+
+		for _, d := range c.nearestWeekdays {
+			if d <= lastDay && day.Day() == nearestWeekday(day, d) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	weekday := int(day.Weekday()) + 1
+	if c.daysOfWeek[weekday] {
+		return true
+	}
+
+	for _, d := range c.lastDaysOfWeek {
+		if d == weekday && day.Day()+7 > lastDay {
+			return true
+		}
+	}
+
+	for _, d := range c.nthDaysOfWeek {
+		if d.weekday == weekday && (day.Day()-1)/7+1 == d.n {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nearestWeekday returns the weekday closest to the given day of the month of t, without leaving the month
+func nearestWeekday(t time.Time, d int) int {
+	lastDay := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	switch time.Date(t.Year(), t.Month(), d, 0, 0, 0, 0, time.UTC).Weekday() {
+	case time.Saturday:
+		if d == 1 {
+			return 3
+		}
+		return d - 1
+	case time.Sunday:
+		if d == lastDay {
+			return d - 2
+		}
+		return d + 1
+	}
+
+	return d
+}
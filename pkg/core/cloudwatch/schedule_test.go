@@ -0,0 +1,115 @@
+package cloudwatch
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextFireTimes(t *testing.T) {
+	tests := []struct {
+		expression string
+		from       string
+		count      int
+		want       []string
+	}{
+		// rate() counts from the given minute
+		{"rate(5 minutes)", "2024-01-01T00:00:30Z", 2, []string{"2024-01-01T00:05:00Z", "2024-01-01T00:10:00Z"}},
+		{"rate(1 hour)", "2024-01-01T00:00:00Z", 2, []string{"2024-01-01T01:00:00Z", "2024-01-01T02:00:00Z"}},
+		{"rate(2 days)", "2024-01-01T00:00:00Z", 1, []string{"2024-01-03T00:00:00Z"}},
+
+		{"cron(0 12 * * ? *)", "2024-01-01T00:00:00Z", 2, []string{"2024-01-01T12:00:00Z", "2024-01-02T12:00:00Z"}},
+		// Fire times are strictly after the given time
+		{"cron(0 12 * * ? *)", "2024-01-01T12:00:00Z", 1, []string{"2024-01-02T12:00:00Z"}},
+
+		// Ranges of hours and named weekdays, 2024-01-05 is a Friday
+		{"cron(0 9-11 ? * MON-FRI *)", "2024-01-05T10:30:00Z", 2, []string{"2024-01-05T11:00:00Z", "2024-01-08T09:00:00Z"}},
+
+		// Increments
+		{"cron(5/20 * * * ? *)", "2024-01-01T00:00:00Z", 4, []string{"2024-01-01T00:05:00Z", "2024-01-01T00:25:00Z", "2024-01-01T00:45:00Z", "2024-01-01T01:05:00Z"}},
+		{"cron(5/1 * * * ? *)", "2024-01-01T00:58:00Z", 3, []string{"2024-01-01T00:59:00Z", "2024-01-01T01:05:00Z", "2024-01-01T01:06:00Z"}},
+		{"cron(*/15 * * * ? *)", "2024-01-01T00:00:00Z", 2, []string{"2024-01-01T00:15:00Z", "2024-01-01T00:30:00Z"}},
+		{"cron(0 0-6/3 * * ? *)", "2024-01-01T00:00:00Z", 3, []string{"2024-01-01T03:00:00Z", "2024-01-01T06:00:00Z", "2024-01-02T00:00:00Z"}},
+
+		// Last day of the month, 2024 is a leap year
+		{"cron(0 0 L * ? *)", "2024-01-01T00:00:00Z", 3, []string{"2024-01-31T00:00:00Z", "2024-02-29T00:00:00Z", "2024-03-31T00:00:00Z"}},
+		// Last Friday of the month
+		{"cron(0 0 ? * 6L *)", "2024-01-01T00:00:00Z", 3, []string{"2024-01-26T00:00:00Z", "2024-02-23T00:00:00Z", "2024-03-29T00:00:00Z"}},
+
+		// Nearest weekday: 2024-06-15 is a Saturday, 2024-09-15 a Sunday
+		{"cron(0 0 15W 6,9 ? 2024)", "2024-01-01T00:00:00Z", 5, []string{"2024-06-14T00:00:00Z", "2024-09-16T00:00:00Z"}},
+		// 2024-06-01 is a Saturday, the nearest weekday within the month is Monday the 3rd
+		{"cron(0 0 1W JUN ? 2024)", "2024-01-01T00:00:00Z", 5, []string{"2024-06-03T00:00:00Z"}},
+		// 2024-08-31 is a Saturday
+		{"cron(0 0 LW AUG ? 2024)", "2024-01-01T00:00:00Z", 5, []string{"2024-08-30T00:00:00Z"}},
+
+		// Second Monday of the month
+		{"cron(0 10 ? * MON#2 *)", "2024-01-01T00:00:00Z", 3, []string{"2024-01-08T10:00:00Z", "2024-02-12T10:00:00Z", "2024-03-11T10:00:00Z"}},
+		{"cron(0 10 ? * 2#1 *)", "2024-01-01T00:00:00Z", 2, []string{"2024-01-01T10:00:00Z", "2024-02-05T10:00:00Z"}},
+
+		// Day of week 1 is Sunday
+		{"cron(0 8 ? * 1 *)", "2024-01-01T00:00:00Z", 1, []string{"2024-01-07T08:00:00Z"}},
+		{"cron(0 8 ? * SUN *)", "2024-01-01T00:00:00Z", 1, []string{"2024-01-07T08:00:00Z"}},
+
+		// Named months
+		{"cron(0 0 1 JAN,JUL ? *)", "2024-01-01T00:00:00Z", 2, []string{"2024-07-01T00:00:00Z", "2025-01-01T00:00:00Z"}},
+
+		// Year field, there are no fire times after the last year
+		{"cron(0 0 1 1 ? 2026-2027)", "2024-01-01T00:00:00Z", 5, []string{"2026-01-01T00:00:00Z", "2027-01-01T00:00:00Z"}},
+		{"cron(0 0 1 1 ? 2020)", "2024-01-01T00:00:00Z", 5, []string{}},
+	}
+
+	for _, test := range tests {
+		schedule, err := ParseSchedule(test.expression)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.expression, err)
+			continue
+		}
+
+		from, _ := time.Parse(time.RFC3339, test.from)
+		got := NextFireTimes(schedule, from, test.count)
+
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got %d fire times, want %d", test.expression, len(got), len(test.want))
+			continue
+		}
+
+		for i, want := range test.want {
+			if got[i].Format(time.RFC3339) != want {
+				t.Errorf("%s from %s: fire time %d is %s, want %s", test.expression, test.from, i, got[i].Format(time.RFC3339), want)
+			}
+		}
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	tests := []string{
+		"every 5 minutes",
+		"rate(5)",
+		"rate(0 minutes)",
+		"rate(1 minutes)",
+		"rate(5 minute)",
+		"rate(2 weeks)",
+		"cron(0 12 * * ?)",
+		// Exactly one of day of month and day of week is ?
+		"cron(0 12 * * * *)",
+		"cron(0 12 ? * ? *)",
+		"cron(60 * * * ? *)",
+		"cron(0 24 * * ? *)",
+		"cron(0 0 32 * ? *)",
+		"cron(0 0 * 13 ? *)",
+		"cron(0 0 * FOO ? *)",
+		"cron(0 0 ? * 8 *)",
+		"cron(5/0 * * * ? *)",
+		"cron(10-5 * * * ? *)",
+		"cron(0 0 ? * MON#6 *)",
+		"cron(0 0 ? * 9L *)",
+		"cron(0 0 32W * ? *)",
+		"cron(0 0 1 1 ? 1969)",
+	}
+
+	for _, expression := range tests {
+		if _, err := ParseSchedule(expression); err == nil {
+			t.Errorf("%s: expected an error", expression)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bitbucket.org/agrim123/onyx/pkg/core/ecs"
+	"bitbucket.org/agrim123/onyx/pkg/logger"
+	"bitbucket.org/agrim123/onyx/pkg/utils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchLib "github.com/aws/aws-sdk-go-v2/service/cloudwatchevents"
+	cloudwatchTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchevents/types"
+	ecsLib "github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecsTypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+// Target ids are at most 64 letters, numbers, dots, hyphens and underscores
+const maxTargetIDLength = 64
+
+// ecsTargetID is the id of the ECS target onyx creates for a task definition family in a cluster
+func ecsTargetID(clusterName, family string) string {
+	id := []rune("onyx-" + clusterName + "-" + family)
+	for i, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_') {
+			id[i] = '-'
+		}
+	}
+
+	if len(id) > maxTargetIDLength {
+		id = id[:maxTargetIDLength]
+	}
+
+	return string(id)
+}
+
+type ScheduledTaskInput struct {
+	Name           string
+	Description    string
+	Schedule       string
+	ClusterName    string
+	TaskDefinition string
+	RoleArn        string
+	// ServiceName is the service whose network configuration and launch type are copied
+	ServiceName   string
+	ContainerName string
+	Command       string
+	Count         int32
+}
+
+// PutScheduledTask creates or updates a scheduled rule along with its ECS task target.
+// Network configuration and launch type are copied from the given service, or from a service
+// of the cluster running the same task definition family.
+func PutScheduledTask(ctx context.Context, cfg aws.Config, input ScheduledTaskInput) error {
+	if err := ValidateSchedule(input.Schedule); err != nil {
+		return err
+	}
+
+	if input.Count < 1 {
+		return errors.New("count must be at least 1")
+	}
+
+	taskDefinition, err := ecs.DescribeTaskDefinition(ctx, cfg, input.TaskDefinition)
+	if err != nil {
+		return err
+	}
+
+	// Without a revision, the rule follows the latest ACTIVE revision of the family
+	taskDefinitionArn := aws.ToString(taskDefinition.TaskDefinitionArn)
+	a := strings.Split(input.TaskDefinition, "/")
+	if !strings.Contains(a[len(a)-1], ":") {
+		taskDefinitionArn = taskDefinitionArn[:strings.LastIndex(taskDefinitionArn, ":")]
+	}
+
+	service, err := scheduledTaskService(ctx, cfg, input.ClusterName, input.ServiceName, aws.ToString(taskDefinition.Family))
+	if err != nil {
+		return err
+	}
+
+	if service == nil && taskDefinition.NetworkMode == ecsTypes.NetworkModeAwsvpc {
+		return fmt.Errorf("%s uses awsvpc network mode and no service of %s runs it. Use --from-service to copy network configuration from one", aws.ToString(taskDefinition.Family), input.ClusterName)
+	}
+
+	ecsHandler := ecsLib.NewFromConfig(cfg)
+	clustersOutput, err := ecsHandler.DescribeClusters(ctx, &ecsLib.DescribeClustersInput{
+		Clusters: []string{input.ClusterName},
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(clustersOutput.Clusters) == 0 {
+		return errors.New("cluster " + input.ClusterName + " not found")
+	}
+
+	parameters := &cloudwatchTypes.EcsParameters{
+		TaskDefinitionArn: aws.String(taskDefinitionArn),
+		TaskCount:         aws.Int32(input.Count),
+		LaunchType:        cloudwatchTypes.LaunchTypeEc2,
+	}
+
+	for _, compatibility := range taskDefinition.RequiresCompatibilities {
+		if compatibility == ecsTypes.CompatibilityFargate {
+			parameters.LaunchType = cloudwatchTypes.LaunchTypeFargate
+		}
+	}
+
+	if service != nil {
+		logger.Info("Copying network configuration and launch type from %s", logger.Bold(aws.ToString(service.ServiceName)))
+
+		if service.LaunchType != "" {
+			parameters.LaunchType = cloudwatchTypes.LaunchType(service.LaunchType)
+		} else if len(service.CapacityProviderStrategy) > 0 {
+			logger.Warn("%s uses a capacity provider strategy, which rule targets do not support. Using launch type %s", aws.ToString(service.ServiceName), parameters.LaunchType)
+		}
+
+		if parameters.LaunchType == cloudwatchTypes.LaunchTypeFargate {
+			parameters.PlatformVersion = service.PlatformVersion
+		}
+
+		if service.NetworkConfiguration != nil && service.NetworkConfiguration.AwsvpcConfiguration != nil {
+			vpcConfiguration := service.NetworkConfiguration.AwsvpcConfiguration
+			parameters.NetworkConfiguration = &cloudwatchTypes.NetworkConfiguration{
+				AwsvpcConfiguration: &cloudwatchTypes.AwsVpcConfiguration{
+					Subnets:        vpcConfiguration.Subnets,
+					SecurityGroups: vpcConfiguration.SecurityGroups,
+					AssignPublicIp: cloudwatchTypes.AssignPublicIp(vpcConfiguration.AssignPublicIp),
+				},
+			}
+		}
+	}
+
+	target := cloudwatchTypes.Target{
+		Id:            aws.String(ecsTargetID(input.ClusterName, aws.ToString(taskDefinition.Family))),
+		Arn:           clustersOutput.Clusters[0].ClusterArn,
+		RoleArn:       aws.String(input.RoleArn),
+		EcsParameters: parameters,
+	}
+
+	if input.Command != "" {
+		containerName := input.ContainerName
+		if containerName == "" {
+			containerName = aws.ToString(taskDefinition.ContainerDefinitions[0].Name)
+			for _, container := range taskDefinition.ContainerDefinitions {
+				if aws.ToBool(container.Essential) {
+					containerName = aws.ToString(container.Name)
+					break
+				}
+			}
+		}
+
+		command, err := utils.SplitCommand(input.Command)
+		if err != nil {
+			return err
+		}
+
+		// The SDK types have no json tags, the target input uses the lower camel case API names
+		overrides, err := json.Marshal(map[string]interface{}{
+			"containerOverrides": []map[string]interface{}{{
+				"name":    containerName,
+				"command": command,
+			}},
+		})
+		if err != nil {
+			return err
+		}
+
+		target.Input = aws.String(string(overrides))
+	}
+
+	cloudwatchHandler := cloudwatchLib.NewFromConfig(cfg)
+
+	// Updating a rule keeps its state, new rules are enabled
+	state := cloudwatchTypes.RuleStateEnabled
+	existing, err := cloudwatchHandler.DescribeRule(ctx, &cloudwatchLib.DescribeRuleInput{
+		Name: aws.String(input.Name),
+	})
+	if err == nil {
+		state = existing.State
+
+		targets, err := listTargets(ctx, cfg, input.Name)
+		if err != nil {
+			return err
+		}
+
+		// Replace the existing ECS target running the family in the cluster instead of adding another one,
+		// keeping its command override unless a new command is given
+		for _, existingTarget := range targets {
+			if existingTarget.EcsParameters == nil || aws.ToString(existingTarget.Arn) != aws.ToString(target.Arn) {
+				continue
+			}
+
+			a := strings.Split(aws.ToString(existingTarget.EcsParameters.TaskDefinitionArn), "/")
+			if strings.SplitN(a[len(a)-1], ":", 2)[0] != aws.ToString(taskDefinition.Family) {
+				continue
+			}
+
+			target.Id = existingTarget.Id
+			if input.Command == "" {
+				target.Input = existingTarget.Input
+			}
+			break
+		}
+	} else {
+		var notFound *cloudwatchTypes.ResourceNotFoundException
+		if !errors.As(err, &notFound) {
+			return err
+		}
+	}
+
+	putRuleInput := &cloudwatchLib.PutRuleInput{
+		Name:               aws.String(input.Name),
+		ScheduleExpression: aws.String(input.Schedule),
+		State:              state,
+	}
+	if input.Description != "" {
+		putRuleInput.Description = aws.String(input.Description)
+	}
+
+	if _, err := cloudwatchHandler.PutRule(ctx, putRuleInput); err != nil {
+		return err
+	}
+
+	output, err := cloudwatchHandler.PutTargets(ctx, &cloudwatchLib.PutTargetsInput{
+		Rule:    aws.String(input.Name),
+		Targets: []cloudwatchTypes.Target{target},
+	})
+	if err != nil {
+		return err
+	}
+
+	if output.FailedEntryCount > 0 {
+		return fmt.Errorf("rule %s saved but its target was rejected. Reason: %s", input.Name, aws.ToString(output.FailedEntries[0].ErrorMessage))
+	}
+
+	if existing != nil {
+		logger.Success("Updated rule %s (%s)", logger.Bold(input.Name), state)
+	} else {
+		logger.Success("Created rule %s", logger.Bold(input.Name))
+	}
+	fmt.Println("  Schedule:", input.Schedule)
+	fmt.Println("  Target:  ", targetToString(target))
+
+	schedule, _ := ParseSchedule(input.Schedule)
+	if next, ok := schedule.Next(time.Now()); ok && state == cloudwatchTypes.RuleStateEnabled {
+		fmt.Println("  Next run:", next.Local().Format("Mon, 02 Jan 2006 15:04 MST"))
+	}
+
+	return nil
+}
+
+// scheduledTaskService returns the service to copy network configuration from. Without a service name,
+// the first service of the cluster running the task definition family is used, nil if there is none.
+func scheduledTaskService(ctx context.Context, cfg aws.Config, clusterName, serviceName, family string) (*ecsTypes.Service, error) {
+	if serviceName != "" {
+		return ecs.DescribeService(ctx, cfg, clusterName, serviceName)
+	}
+
+	cluster := ecs.Cluster{
+		Name: clusterName,
+	}
+
+	if err := cluster.GetServices(ctx, cfg, ""); err != nil {
+		return nil, err
+	}
+
+	for _, service := range cluster.Services {
+		a := strings.Split(service.TaskDefinitionArn, "/")
+		if strings.SplitN(a[len(a)-1], ":", 2)[0] == family {
+			return ecs.DescribeService(ctx, cfg, clusterName, service.Name)
+		}
+	}
+
+	return nil, nil
+}
+
+// DeleteRule removes all targets of the rule and then the rule itself
+func DeleteRule(ctx context.Context, cfg aws.Config, name string) error {
+	targets, err := listTargets(ctx, cfg, name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Rule:", name)
+	for _, target := range targets {
+		fmt.Println("  Target:", targetToString(target))
+	}
+
+	confirmation := strings.TrimSpace(utils.GetUserInput(fmt.Sprintf("Delete rule %s and its %d target(s)? [y/N]: ", name, len(targets))))
+	if confirmation != "y" && confirmation != "Y" {
+		logger.Info("Aborted")
+		return nil
+	}
+
+	ids := make([]string, 0)
+	for _, target := range targets {
+		ids = append(ids, aws.ToString(target.Id))
+	}
+
+	cloudwatchHandler := cloudwatchLib.NewFromConfig(cfg)
+	for _, chunk := range utils.GetChunks(ids, 100) {
+		output, err := cloudwatchHandler.RemoveTargets(ctx, &cloudwatchLib.RemoveTargetsInput{
+			Rule: aws.String(name),
+			Ids:  chunk,
+		})
+		if err != nil {
+			return err
+		}
+
+		if output.FailedEntryCount > 0 {
+			return fmt.Errorf("unable to remove target %s. Reason: %s", aws.ToString(output.FailedEntries[0].TargetId), aws.ToString(output.FailedEntries[0].ErrorMessage))
+		}
+	}
+
+	_, err = cloudwatchHandler.DeleteRule(ctx, &cloudwatchLib.DeleteRuleInput{
+		Name: aws.String(name),
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Success("Deleted rule %s", logger.Bold(name))
+	return nil
+}
@@ -0,0 +1,205 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"bitbucket.org/agrim123/onyx/pkg/logger"
+	"bitbucket.org/agrim123/onyx/pkg/utils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchLib "github.com/aws/aws-sdk-go-v2/service/cloudwatchevents"
+	cloudwatchTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchevents/types"
+)
+
+// RuleState is the state of a single rule recorded in a snapshot
+type RuleState struct {
+	Name  string                    `json:"name"`
+	State cloudwatchTypes.RuleState `json:"state"`
+}
+
+// Snapshot records the states of rules before a bulk disable so they can be restored later
+type Snapshot struct {
+	CreatedAt time.Time   `json:"created_at"`
+	Prefix    string      `json:"prefix,omitempty"`
+	Tags      []string    `json:"tags,omitempty"`
+	Rules     []RuleState `json:"rules"`
+}
+
+// DefaultSnapshotPath is the file a snapshot is written to when none is given
+func DefaultSnapshotPath() string {
+	return fmt.Sprintf("cw-rules-snapshot-%s.json", time.Now().Format("20060102-150405"))
+}
+
+// parseKeyValues converts `key=value` filters, like tags or dimensions, into a map
+func parseKeyValues(kind string, values []string) (map[string]string, error) {
+	parsed := make(map[string]string)
+	for _, value := range values {
+		pair := strings.SplitN(value, "=", 2)
+		if len(pair) != 2 || pair[0] == "" {
+			return nil, errors.New("invalid " + kind + ": " + value + ". Expected KEY=VALUE")
+		}
+
+		parsed[pair[0]] = pair[1]
+	}
+
+	return parsed, nil
+}
+
+// hasTags reports whether the rule carries every given tag
+func hasTags(ctx context.Context, cfg aws.Config, ruleArn string, tags map[string]string) (bool, error) {
+	if len(tags) == 0 {
+		return true, nil
+	}
+
+	cloudwatchHandler := cloudwatchLib.NewFromConfig(cfg)
+	output, err := cloudwatchHandler.ListTagsForResource(ctx, &cloudwatchLib.ListTagsForResourceInput{
+		ResourceARN: aws.String(ruleArn),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	ruleTags := make(map[string]string)
+	for _, tag := range output.Tags {
+		ruleTags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+
+	for key, value := range tags {
+		if ruleValue, ok := ruleTags[key]; !ok || ruleValue != value {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// DisableRules disables every rule matching the prefix and all of the tags after writing their current states to snapshotPath
+func DisableRules(ctx context.Context, cfg aws.Config, prefix string, tags []string, snapshotPath string) error {
+	// Without filters every rule in the account would match
+	if prefix == "" && len(tags) == 0 {
+		return errors.New("a prefix or tag is required to match rules")
+	}
+
+	tagFilters, err := parseKeyValues("tag", tags)
+	if err != nil {
+		return err
+	}
+
+	rules, err := listRules(ctx, cfg, prefix)
+	if err != nil {
+		return err
+	}
+
+	snapshot := Snapshot{
+		CreatedAt: time.Now(),
+		Prefix:    prefix,
+		Tags:      tags,
+		Rules:     make([]RuleState, 0),
+	}
+
+	toDisable := make([]string, 0)
+	for _, rule := range rules {
+		matched, err := hasTags(ctx, cfg, rule.Arn, tagFilters)
+		if err != nil {
+			return err
+		}
+
+		if !matched {
+			continue
+		}
+
+		snapshot.Rules = append(snapshot.Rules, RuleState{Name: rule.Name, State: rule.State})
+		if rule.State == cloudwatchTypes.RuleStateEnabled {
+			toDisable = append(toDisable, rule.Name)
+		}
+	}
+
+	if len(snapshot.Rules) == 0 {
+		return errors.New("no rules matched")
+	}
+
+	if len(toDisable) == 0 {
+		logger.Info("All %d matched rule(s) are already disabled", len(snapshot.Rules))
+		return nil
+	}
+
+	for _, name := range toDisable {
+		fmt.Println("  ", name)
+	}
+
+	confirmation := strings.TrimSpace(utils.GetUserInput(fmt.Sprintf("Disable %d rule(s)? [y/N]: ", len(toDisable))))
+	if confirmation != "y" && confirmation != "Y" {
+		logger.Info("Aborted")
+		return nil
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// The snapshot is written before any change so a partial failure can still be restored
+	if err := ioutil.WriteFile(snapshotPath, data, 0644); err != nil {
+		return errors.New("unable to write snapshot. Error: " + err.Error())
+	}
+	logger.Info("Saved prior states of %d rule(s) to %s", len(snapshot.Rules), logger.Bold(snapshotPath))
+
+	failed := 0
+	for _, name := range toDisable {
+		if err := DisableRule(ctx, cfg, name); err != nil {
+			logger.Error("Unable to disable %s. Error: %s", logger.Bold(name), err.Error())
+			failed++
+			continue
+		}
+
+		logger.Success("Disabled %s", logger.Bold(name))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("unable to disable %d rule(s)", failed)
+	}
+
+	logger.Info("Restore with `onyx cw restore %s`", snapshotPath)
+	return nil
+}
+
+// RestoreRules re-enables the rules that were enabled when the snapshot was taken
+func RestoreRules(ctx context.Context, cfg aws.Config, snapshotPath string) error {
+	data, err := ioutil.ReadFile(snapshotPath)
+	if err != nil {
+		return err
+	}
+
+	snapshot := Snapshot{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return errors.New("invalid snapshot " + snapshotPath + ". Error: " + err.Error())
+	}
+
+	logger.Info("Restoring rules from snapshot taken at %s", snapshot.CreatedAt.Format(time.RFC1123))
+
+	failed := 0
+	for _, rule := range snapshot.Rules {
+		if rule.State != cloudwatchTypes.RuleStateEnabled {
+			continue
+		}
+
+		if err := EnableRule(ctx, cfg, rule.Name); err != nil {
+			logger.Error("Unable to enable %s. Error: %s", logger.Bold(rule.Name), err.Error())
+			failed++
+			continue
+		}
+
+		logger.Success("Enabled %s", logger.Bold(rule.Name))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("unable to enable %d rule(s)", failed)
+	}
+
+	return nil
+}
@@ -9,9 +9,11 @@ import (
 )
 
 type Instance struct {
-	ID          string
-	PublicIPv4  string
-	PrivateIPv4 string
+	ID               string
+	PublicIPv4       string
+	PrivateIPv4      string
+	InstanceType     string
+	AvailabilityZone string
 }
 
 func DescribeInstances(ctx context.Context, cfg aws.Config, instanceIDs []string) (*[]Instance, error) {
@@ -25,17 +27,22 @@ func DescribeInstances(ctx context.Context, cfg aws.Config, instanceIDs []string
 		return &instances, err
 	}
 
+	// Instances launched together share a reservation
 	for _, reservation := range ec2DetailsOutput.Reservations {
-		publicIpv4 := ""
-		if reservation.Instances[0].PublicIpAddress != nil {
-			publicIpv4 = *reservation.Instances[0].PublicIpAddress
-		}
+		for _, instance := range reservation.Instances {
+			availabilityZone := ""
+			if instance.Placement != nil {
+				availabilityZone = aws.ToString(instance.Placement.AvailabilityZone)
+			}
 
-		instances = append(instances, Instance{
-			ID:          *reservation.Instances[0].InstanceId,
-			PrivateIPv4: *reservation.Instances[0].PrivateIpAddress,
-			PublicIPv4:  publicIpv4,
-		})
+			instances = append(instances, Instance{
+				ID:               aws.ToString(instance.InstanceId),
+				PrivateIPv4:      aws.ToString(instance.PrivateIpAddress),
+				PublicIPv4:       aws.ToString(instance.PublicIpAddress),
+				InstanceType:     string(instance.InstanceType),
+				AvailabilityZone: availabilityZone,
+			})
+		}
 	}
 
 	return &instances, nil
@@ -66,3 +73,16 @@ func StartInstance(ctx context.Context, cfg aws.Config, instanceID string) error
 	logger.Success("Started instance %s", instanceID)
 	return nil
 }
+
+func TerminateInstance(ctx context.Context, cfg aws.Config, instanceID string) error {
+	ec2Handler := ec2Lib.NewFromConfig(cfg)
+	_, err := ec2Handler.TerminateInstances(ctx, &ec2Lib.TerminateInstancesInput{
+		InstanceIds: []string{instanceID},
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Success("Terminated instance %s", instanceID)
+	return nil
+}
@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"os"
 	"strconv"
 	"strings"
+	"text/tabwriter"
 
 	"bitbucket.org/agrim123/onyx/pkg/core/iam"
 	"bitbucket.org/agrim123/onyx/pkg/logger"
@@ -536,8 +538,27 @@ func AuthorizeOrRevokeRule(
 		return nil
 	}
 
+	identity, err := iam.GetCallerIdentity(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	actions := []string{"ec2:RevokeSecurityGroupIngress"}
+	if authorize {
+		actions = append(actions, "ec2:AuthorizeSecurityGroupIngress")
+	}
+
+	securityGroupArns := make([]string, 0)
+	for id := range securityGroups {
+		securityGroupArns = append(securityGroupArns, fmt.Sprintf("arn:aws:ec2:%s:%s:security-group/%s", cfg.Region, identity.Account, id))
+	}
+
 	publicIP := utils.GetPublicIP()
 
+	if err := identity.Preflight(ctx, cfg, actions, securityGroupArns, iam.SourceIPContext(publicIP)); err != nil {
+		return err
+	}
+
 	for _, sgAlter := range securityGroups {
 		ports := make([]int32, 0)
 		for port := range sgAlter.Ports {
@@ -567,3 +588,79 @@ func AuthorizeOrRevokeRule(
 
 	return nil
 }
+
+// UserRule is an ingress rule authorized through onyx by a user
+type UserRule struct {
+	SecurityGroupID   string
+	SecurityGroupName string
+	Port              int32
+	Protocol          string
+	Cidr              string
+}
+
+// ListUserRules scans all security groups for ingress rules whose description attributes them to the user
+func ListUserRules(ctx context.Context, cfg aws.Config, user string) ([]UserRule, error) {
+	sgRule, _ := NewSecurityGroupRule(0, user)
+	description := sgRule.enrichRuleDescription()
+
+	ec2Handler := ec2Lib.NewFromConfig(cfg)
+
+	userRules := make([]UserRule, 0)
+
+	var nextToken *string
+	for {
+		output, err := ec2Handler.DescribeSecurityGroups(ctx, &ec2Lib.DescribeSecurityGroupsInput{
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, securityGroup := range convertSecurityGroups(&output.SecurityGroups) {
+			for _, rule := range securityGroup.rules {
+				if rule.description != description {
+					continue
+				}
+
+				userRules = append(userRules, UserRule{
+					SecurityGroupID:   securityGroup.ID,
+					SecurityGroupName: securityGroup.Name,
+					Port:              rule.port,
+					Protocol:          rule.protocol,
+					Cidr:              rule.cidr,
+				})
+			}
+		}
+
+		if output.NextToken == nil {
+			break
+		}
+
+		nextToken = output.NextToken
+	}
+
+	return userRules, nil
+}
+
+// PrintUserRules lists the ingress rules attributed to the user
+func PrintUserRules(ctx context.Context, cfg aws.Config, user string) error {
+	rules, err := ListUserRules(ctx, cfg, user)
+	if err != nil {
+		return err
+	}
+
+	if len(rules) == 0 {
+		logger.Info("No security group rules attributed to %s", logger.Bold(user))
+		return nil
+	}
+
+	logger.Warn("%d security group rule(s) attributed to %s", len(rules), logger.Bold(user))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SECURITY GROUP\tNAME\tPORT\tPROTOCOL\tSOURCE")
+	for _, rule := range rules {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", rule.SecurityGroupID, rule.SecurityGroupName, rule.Port, rule.Protocol, rule.Cidr)
+	}
+	w.Flush()
+
+	return nil
+}
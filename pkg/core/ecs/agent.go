@@ -0,0 +1,218 @@
+package ecs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"bitbucket.org/agrim123/onyx/pkg/logger"
+	"bitbucket.org/agrim123/onyx/pkg/utils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecsLib "github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+const (
+	agentUpdatePollInterval = 10 * time.Second
+	agentUpdateTimeout      = 15 * time.Minute
+)
+
+// agentUpdate tracks the container agent update of a single container instance
+type agentUpdate struct {
+	arn           string
+	instanceID    string
+	versionBefore string
+	versionAfter  string
+	status        string
+}
+
+// UpdateContainerAgent updates the container agent of all container instances of the cluster in rolling batches.
+// Each batch is polled until its updates are UPDATED or FAILED before moving to the next one. The last batch is
+// only waited for if wait is set. An error is returned if any update failed.
+func UpdateContainerAgent(ctx context.Context, cfg aws.Config, clusterName string, batchSize int, wait bool) error {
+	if batchSize < 1 {
+		return errors.New("batch size must be at least 1")
+	}
+
+	arns, err := listContainerInstances(ctx, cfg, clusterName, "")
+	if err != nil {
+		return err
+	}
+
+	containerInstances, err := describeContainerInstances(ctx, cfg, clusterName, arns)
+	if err != nil {
+		return err
+	}
+
+	if len(containerInstances) == 0 {
+		logger.Warn("No container instances registered in %s", logger.Bold(clusterName))
+		return nil
+	}
+
+	updates := make([]*agentUpdate, 0)
+	toUpdate := make([]*agentUpdate, 0)
+	for _, containerInstance := range containerInstances {
+		update := &agentUpdate{
+			arn:           aws.ToString(containerInstance.ContainerInstanceArn),
+			instanceID:    aws.ToString(containerInstance.Ec2InstanceId),
+			versionBefore: agentVersion(containerInstance),
+		}
+		updates = append(updates, update)
+
+		switch {
+		case !containerInstance.AgentConnected:
+			update.status = "SKIPPED (agent disconnected)"
+		case agentUpdateInProgress(containerInstance.AgentUpdateStatus):
+			update.status = "SKIPPED (update already " + string(containerInstance.AgentUpdateStatus) + ")"
+		default:
+			toUpdate = append(toUpdate, update)
+		}
+	}
+
+	if len(toUpdate) == 0 {
+		printAgentUpdateReport(updates)
+		return nil
+	}
+
+	fmt.Println("Cluster Name:", clusterName)
+	fmt.Println("Container instances to update:")
+	for _, update := range toUpdate {
+		fmt.Println("   ", update.instanceID, "(agent", update.versionBefore+")")
+	}
+
+	confirmation := strings.TrimSpace(utils.GetUserInput(fmt.Sprintf("Update container agent on %d instance(s) in batches of %d? [y/N]: ", len(toUpdate), batchSize)))
+	if confirmation != "y" && confirmation != "Y" {
+		logger.Info("Aborted")
+		return nil
+	}
+
+	ecsHandler := ecsLib.NewFromConfig(cfg)
+	for start := 0; start < len(toUpdate); start += batchSize {
+		end := start + batchSize
+		if end > len(toUpdate) {
+			end = len(toUpdate)
+		}
+
+		batch := make([]*agentUpdate, 0)
+		for _, update := range toUpdate[start:end] {
+			_, err := ecsHandler.UpdateContainerAgent(ctx, &ecsLib.UpdateContainerAgentInput{
+				Cluster:           aws.String(clusterName),
+				ContainerInstance: aws.String(update.arn),
+			})
+
+			var noUpdateAvailable *types.NoUpdateAvailableException
+			switch {
+			case errors.As(err, &noUpdateAvailable):
+				update.status = "SKIPPED (already latest)"
+			case err != nil:
+				update.status = "FAILED (" + err.Error() + ")"
+			default:
+				update.status = string(types.AgentUpdateStatusPending)
+				batch = append(batch, update)
+				logger.Info("Requested agent update for %s", logger.Bold(update.instanceID))
+			}
+		}
+
+		last := end == len(toUpdate)
+		if (wait || !last) && len(batch) > 0 {
+			if err := waitForAgentUpdates(ctx, cfg, clusterName, batch); err != nil {
+				printAgentUpdateReport(updates)
+				return err
+			}
+		}
+	}
+
+	printAgentUpdateReport(updates)
+
+	failed := 0
+	for _, update := range updates {
+		if strings.HasPrefix(update.status, string(types.AgentUpdateStatusFailed)) {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("agent update failed on %d container instance(s)", failed)
+	}
+
+	return nil
+}
+
+func waitForAgentUpdates(ctx context.Context, cfg aws.Config, clusterName string, batch []*agentUpdate) error {
+	updatesByArn := make(map[string]*agentUpdate)
+	arns := make([]string, 0)
+	for _, update := range batch {
+		updatesByArn[update.arn] = update
+		arns = append(arns, update.arn)
+	}
+
+	deadline := time.Now().Add(agentUpdateTimeout)
+	for len(arns) > 0 {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for agent updates", agentUpdateTimeout)
+		}
+
+		time.Sleep(agentUpdatePollInterval)
+
+		containerInstances, err := describeContainerInstances(ctx, cfg, clusterName, arns)
+		if err != nil {
+			return err
+		}
+
+		pending := make([]string, 0)
+		for _, containerInstance := range containerInstances {
+			update := updatesByArn[aws.ToString(containerInstance.ContainerInstanceArn)]
+			update.status = string(containerInstance.AgentUpdateStatus)
+			update.versionAfter = agentVersion(containerInstance)
+
+			switch containerInstance.AgentUpdateStatus {
+			case types.AgentUpdateStatusUpdated:
+				logger.Success("Updated agent on %s to %s", logger.Bold(update.instanceID), update.versionAfter)
+			case types.AgentUpdateStatusFailed:
+				logger.Error("Agent update failed on %s", logger.Bold(update.instanceID))
+			default:
+				pending = append(pending, update.arn)
+				fmt.Printf("  %s: %s\n", update.instanceID, update.status)
+			}
+		}
+
+		arns = pending
+	}
+
+	return nil
+}
+
+func agentUpdateInProgress(status types.AgentUpdateStatus) bool {
+	switch status {
+	case types.AgentUpdateStatusPending, types.AgentUpdateStatusStaging, types.AgentUpdateStatusStaged, types.AgentUpdateStatusUpdating:
+		return true
+	}
+
+	return false
+}
+
+func agentVersion(containerInstance types.ContainerInstance) string {
+	if containerInstance.VersionInfo == nil {
+		return "-"
+	}
+
+	return aws.ToString(containerInstance.VersionInfo.AgentVersion)
+}
+
+func printAgentUpdateReport(updates []*agentUpdate) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "INSTANCE\tAGENT BEFORE\tAGENT AFTER\tSTATUS")
+	for _, update := range updates {
+		versionAfter := update.versionAfter
+		if versionAfter == "" {
+			versionAfter = "-"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", update.instanceID, update.versionBefore, versionAfter, update.status)
+	}
+	w.Flush()
+}
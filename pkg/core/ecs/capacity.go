@@ -0,0 +1,188 @@
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"bitbucket.org/agrim123/onyx/pkg/logger"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+// TaskRequirements is the cpu and memory a task reserves on a container instance
+type TaskRequirements struct {
+	CPU    int32
+	Memory int32
+	// StaticHostPort limits placement to one task per instance
+	StaticHostPort bool
+}
+
+// NewTaskRequirements derives the reservation of a task from task level cpu/memory, falling back to the sum of its containers
+func NewTaskRequirements(taskDefinition *types.TaskDefinition) TaskRequirements {
+	requirements := TaskRequirements{}
+
+	for _, container := range taskDefinition.ContainerDefinitions {
+		requirements.CPU += container.Cpu
+
+		if container.Memory != nil {
+			requirements.Memory += *container.Memory
+		} else if container.MemoryReservation != nil {
+			requirements.Memory += *container.MemoryReservation
+		}
+
+		if taskDefinition.NetworkMode != types.NetworkModeAwsvpc {
+			for _, port := range container.PortMappings {
+				if aws.ToInt32(port.HostPort) != 0 || taskDefinition.NetworkMode == types.NetworkModeHost {
+					requirements.StaticHostPort = true
+				}
+			}
+		}
+	}
+
+	if cpu, err := strconv.ParseInt(aws.ToString(taskDefinition.Cpu), 10, 32); err == nil {
+		requirements.CPU = int32(cpu)
+	}
+
+	if memory, err := strconv.ParseInt(aws.ToString(taskDefinition.Memory), 10, 32); err == nil {
+		requirements.Memory = int32(memory)
+	}
+
+	return requirements
+}
+
+// Fits returns how many more copies of the task can be placed on the container instance
+func (r TaskRequirements) Fits(containerInstance *ContainerInstance) int32 {
+	if containerInstance.Status != string(types.ContainerInstanceStatusActive) {
+		return 0
+	}
+
+	fits := int32(-1)
+	if r.CPU > 0 {
+		fits = containerInstance.RemainingCPU / r.CPU
+	}
+
+	if r.Memory > 0 {
+		if byMemory := containerInstance.RemainingMemory / r.Memory; fits < 0 || byMemory < fits {
+			fits = byMemory
+		}
+	}
+
+	if fits < 0 {
+		fits = 0
+	}
+
+	if r.StaticHostPort && fits > 1 {
+		fits = 1
+	}
+
+	return fits
+}
+
+// ClusterCapacity prints registered and remaining resources of every container instance of the cluster.
+// If taskDefinition is given, it also reports whether count more copies of it can be placed.
+func ClusterCapacity(ctx context.Context, cfg aws.Config, clusterName, taskDefinition string, count int32) error {
+	arns, err := listContainerInstances(ctx, cfg, clusterName, "")
+	if err != nil {
+		return err
+	}
+
+	containerInstancesMap, err := mapContainerInstances(ctx, cfg, clusterName, arns)
+	if err != nil {
+		return err
+	}
+
+	if len(containerInstancesMap) == 0 {
+		logger.Warn("No container instances registered in %s", logger.Bold(clusterName))
+		return nil
+	}
+
+	containerInstances := make([]*ContainerInstance, 0)
+	for _, containerInstance := range containerInstancesMap {
+		containerInstances = append(containerInstances, containerInstance)
+	}
+
+	sort.Slice(containerInstances, func(i, j int) bool {
+		if containerInstances[i].Instance.AvailabilityZone != containerInstances[j].Instance.AvailabilityZone {
+			return containerInstances[i].Instance.AvailabilityZone < containerInstances[j].Instance.AvailabilityZone
+		}
+
+		return containerInstances[i].Instance.ID < containerInstances[j].Instance.ID
+	})
+
+	var requirements *TaskRequirements
+	var taskDefinitionName string
+	if taskDefinition != "" {
+		detailedTaskDefinition, err := DescribeTaskDefinition(ctx, cfg, taskDefinition)
+		if err != nil {
+			return err
+		}
+
+		r := NewTaskRequirements(detailedTaskDefinition)
+		requirements = &r
+		taskDefinitionName = fmt.Sprintf("%s:%d", aws.ToString(detailedTaskDefinition.Family), detailedTaskDefinition.Revision)
+	}
+
+	fmt.Println("Cluster name:", clusterName)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := "INSTANCE\tTYPE\tAZ\tSTATUS\tAGENT\tTASKS\tCPU (FREE/TOTAL)\tMEMORY (FREE/TOTAL)"
+	if requirements != nil {
+		header += "\tFITS"
+	}
+	fmt.Fprintln(w, header)
+
+	var totalRemainingCPU, totalRemainingMemory, totalFits int32
+	for _, containerInstance := range containerInstances {
+		line := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%d running, %d pending\t%d/%d\t%d/%d",
+			containerInstance.Instance.ID,
+			containerInstance.Instance.InstanceType,
+			containerInstance.Instance.AvailabilityZone,
+			containerInstance.Status,
+			containerInstance.AgentVersion,
+			containerInstance.RunningTasks,
+			containerInstance.PendingTasks,
+			containerInstance.RemainingCPU,
+			containerInstance.RegisteredCPU,
+			containerInstance.RemainingMemory,
+			containerInstance.RegisteredMemory,
+		)
+
+		if containerInstance.Status == string(types.ContainerInstanceStatusActive) {
+			totalRemainingCPU += containerInstance.RemainingCPU
+			totalRemainingMemory += containerInstance.RemainingMemory
+		}
+
+		if requirements != nil {
+			fits := requirements.Fits(containerInstance)
+			totalFits += fits
+			line += fmt.Sprintf("\t%d", fits)
+		}
+
+		fmt.Fprintln(w, line)
+	}
+	w.Flush()
+
+	fmt.Printf("Free on ACTIVE instances: %d CPU units, %d MiB memory\n", totalRemainingCPU, totalRemainingMemory)
+
+	if requirements == nil {
+		return nil
+	}
+
+	fmt.Printf("%s reserves %d CPU units, %d MiB memory per task", taskDefinitionName, requirements.CPU, requirements.Memory)
+	if requirements.StaticHostPort {
+		fmt.Print(" and uses a static host port (one task per instance)")
+	}
+	fmt.Println()
+
+	if totalFits >= count {
+		logger.Success("Cluster can place %d more copies of %s (requested %d)", totalFits, taskDefinitionName, count)
+	} else {
+		logger.Error("Cluster can place only %d more copies of %s (requested %d)", totalFits, taskDefinitionName, count)
+	}
+
+	return nil
+}
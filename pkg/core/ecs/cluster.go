@@ -2,9 +2,14 @@ package ecs
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"sort"
 	"strings"
+	"text/tabwriter"
 
+	"bitbucket.org/agrim123/onyx/pkg/logger"
 	"bitbucket.org/agrim123/onyx/pkg/utils"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	ecsLib "github.com/aws/aws-sdk-go-v2/service/ecs"
@@ -12,9 +17,12 @@ import (
 )
 
 type Cluster struct {
+	Arn                string
 	Name               string
 	Services           []Service
 	ContainerInstances int
+	// SchedulingStrategy filters services by strategy, all services if empty
+	SchedulingStrategy types.SchedulingStrategy
 }
 
 func (c *Cluster) Print() {
@@ -22,7 +30,7 @@ func (c *Cluster) Print() {
 	// fmt.Println("Registered container instances:", c.ContainerInstances)
 
 	for _, service := range c.Services {
-		fmt.Println("Service Name:", service.Name)
+		fmt.Println("Service Name:", service.Name, "("+service.SchedulingStrategy+")")
 		// fmt.Println("  Task Definition:", service.TaskDefinitionArn)
 		fmt.Println("  Tasks:")
 		for _, task := range service.Tasks {
@@ -43,7 +51,7 @@ func (c *Cluster) GetServices(ctx context.Context, cfg aws.Config, serviceName s
 		allServicesOutput, err := ecsHandler.ListServices(ctx, &ecsLib.ListServicesInput{
 			Cluster:            aws.String(c.Name),
 			NextToken:          nextToken,
-			SchedulingStrategy: types.SchedulingStrategyReplica,
+			SchedulingStrategy: c.SchedulingStrategy,
 		})
 		if err != nil {
 			return err
@@ -58,19 +66,20 @@ func (c *Cluster) GetServices(ctx context.Context, cfg aws.Config, serviceName s
 		nextToken = allServicesOutput.NextToken
 	}
 
-	requiredServiceArns := make([]string, 0)
-	if serviceName != "" {
-		for _, serviceArn := range allServicesArns {
-			if strings.Contains(serviceArn, serviceName) {
-				requiredServiceArns = append(requiredServiceArns, serviceArn)
-			}
-		}
-	} else {
-		requiredServiceArns = allServicesArns
+	matcher, err := NewMatcher(serviceName)
+	if err != nil {
+		return err
+	}
+
+	allServicesNames := extractServiceNameFromServiceArns(c.Name, allServicesArns)
+
+	requiredServiceNames := matcher.Filter(allServicesNames)
+	if len(requiredServiceNames) == 0 && serviceName != "" {
+		return matcher.NotFoundError(allServicesNames, c.Name)
 	}
 
 	servicesFromAWS := make([]types.Service, 0)
-	for _, chunk := range utils.GetChunks(requiredServiceArns, 9) {
+	for _, chunk := range utils.GetChunks(requiredServiceNames, 9) {
 		servicesOutput, err := ecsHandler.DescribeServices(ctx, &ecsLib.DescribeServicesInput{
 			Cluster:  aws.String(c.Name),
 			Services: chunk,
@@ -82,9 +91,14 @@ func (c *Cluster) GetServices(ctx context.Context, cfg aws.Config, serviceName s
 
 	for _, service := range servicesFromAWS {
 		allServices = append(allServices, Service{
-			Arn:               service.ServiceArn,
-			Name:              *service.ServiceName,
-			TaskDefinitionArn: *service.TaskDefinition,
+			Arn:                service.ServiceArn,
+			Name:               *service.ServiceName,
+			TaskDefinitionArn:  *service.TaskDefinition,
+			DesiredCount:       service.DesiredCount,
+			RunningCount:       service.RunningCount,
+			PendingCount:       service.PendingCount,
+			DeploymentState:    deploymentState(service.Deployments),
+			SchedulingStrategy: string(service.SchedulingStrategy),
 		})
 	}
 
@@ -93,40 +107,143 @@ func (c *Cluster) GetServices(ctx context.Context, cfg aws.Config, serviceName s
 	return nil
 }
 
-func (c *Cluster) FilterServicesByName(serviceName string) {
-	if serviceName != "" {
-		filteredServices := make([]Service, 0)
-		for _, service := range c.Services {
-			if strings.Contains(service.Name, serviceName) {
-				filteredServices = append(filteredServices, service)
-			}
-		}
+// SelectServices prompts the user to choose one or more services of the cluster
+func (c *Cluster) SelectServices(message string) ([]Service, error) {
+	if len(c.Services) == 0 {
+		return nil, errors.New("no services found in cluster " + c.Name)
+	}
 
-		c.Services = filteredServices
+	fmt.Println("Cluster Name:", c.Name)
+	fmt.Println(message)
+	for i, service := range c.Services {
+		fmt.Println(logger.Bold(i), ":", service.Name, "("+service.SchedulingStrategy+")")
 	}
-}
 
-func ListClusters(ctx context.Context, cfg aws.Config, nameFilter string) (*[]Cluster, error) {
-	ecsHandler := ecsLib.NewFromConfig(cfg)
-	output, err := ecsHandler.ListClusters(ctx, &ecsLib.ListClustersInput{})
+	indexes, err := utils.ParseSelection(utils.GetUserInput("Enter choice (e.g. 0,2 | 0-3 | all): "), len(c.Services))
 	if err != nil {
 		return nil, err
 	}
 
+	services := make([]Service, 0)
+	for _, i := range indexes {
+		services = append(services, c.Services[i])
+	}
+
+	return services, nil
+}
+
+func ListClusters(ctx context.Context, cfg aws.Config, nameFilter string) (*[]Cluster, error) {
+	ecsHandler := ecsLib.NewFromConfig(cfg)
+
 	clusters := make([]Cluster, 0)
-	for _, arn := range output.ClusterArns {
-		if nameFilter == "" {
-			clusters = append(clusters, Cluster{
-				Name: arn,
-			})
-		} else {
-			if strings.Contains(arn, nameFilter) {
+
+	var nextToken *string
+	for {
+		output, err := ecsHandler.ListClusters(ctx, &ecsLib.ListClustersInput{
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, arn := range output.ClusterArns {
+			a := strings.Split(arn, "/")
+			name := a[len(a)-1]
+
+			if nameFilter == "" || strings.Contains(name, nameFilter) {
 				clusters = append(clusters, Cluster{
-					Name: arn,
+					Arn:  arn,
+					Name: name,
 				})
 			}
 		}
+
+		if output.NextToken == nil {
+			break
+		}
+
+		nextToken = output.NextToken
 	}
 
 	return &clusters, nil
 }
+
+// PrintClusters lists the clusters matching nameFilter with their service, task and instance counts
+func PrintClusters(ctx context.Context, cfg aws.Config, nameFilter string) error {
+	clusters, err := ListClusters(ctx, cfg, nameFilter)
+	if err != nil {
+		return err
+	}
+
+	arns := make([]string, 0)
+	for _, cluster := range *clusters {
+		arns = append(arns, cluster.Arn)
+	}
+
+	ecsHandler := ecsLib.NewFromConfig(cfg)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CLUSTER\tSTATUS\tSERVICES\tRUNNING\tPENDING\tINSTANCES\tCAPACITY PROVIDERS")
+	for _, chunk := range utils.GetChunks(arns, 100) {
+		output, err := ecsHandler.DescribeClusters(ctx, &ecsLib.DescribeClustersInput{
+			Clusters: chunk,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, cluster := range output.Clusters {
+			capacityProviders := "-"
+			if len(cluster.CapacityProviders) > 0 {
+				capacityProviders = strings.Join(cluster.CapacityProviders, ",")
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%s\n",
+				aws.ToString(cluster.ClusterName),
+				aws.ToString(cluster.Status),
+				cluster.ActiveServicesCount,
+				cluster.RunningTasksCount,
+				cluster.PendingTasksCount,
+				cluster.RegisteredContainerInstancesCount,
+				capacityProviders,
+			)
+		}
+	}
+	w.Flush()
+
+	return nil
+}
+
+// PrintServices lists the services of the cluster with their task counts and deployment state,
+// highlighting services running fewer tasks than desired.
+func (c *Cluster) PrintServices() {
+	fmt.Println("Cluster name:", c.Name)
+
+	sort.Slice(c.Services, func(i, j int) bool {
+		return c.Services[i].Name < c.Services[j].Name
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tSTRATEGY\tDESIRED\tRUNNING\tPENDING\tDEPLOYMENT\tTASK DEFINITION")
+	for _, service := range c.Services {
+		family, revision := parseTaskDefinitionArn(service.TaskDefinitionArn)
+
+		line := fmt.Sprintf("%s\t%s\t%d\t%d\t%d\t%s\t%s:%d",
+			service.Name,
+			service.SchedulingStrategy,
+			service.DesiredCount,
+			service.RunningCount,
+			service.PendingCount,
+			service.DeploymentState,
+			family,
+			revision,
+		)
+
+		if service.RunningCount < service.DesiredCount {
+			line += logger.Red("    <------- running < desired")
+		}
+
+		fmt.Fprintln(w, line)
+	}
+	w.Flush()
+}
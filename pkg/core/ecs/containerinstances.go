@@ -0,0 +1,154 @@
+package ecs
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"bitbucket.org/agrim123/onyx/pkg/core/ec2"
+	"bitbucket.org/agrim123/onyx/pkg/utils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecsLib "github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+// listContainerInstances returns arns of all container instances of the cluster matching the optional cluster query language filter
+func listContainerInstances(ctx context.Context, cfg aws.Config, clusterName, filter string) ([]string, error) {
+	ecsHandler := ecsLib.NewFromConfig(cfg)
+
+	input := &ecsLib.ListContainerInstancesInput{
+		Cluster: aws.String(clusterName),
+	}
+	if filter != "" {
+		input.Filter = aws.String(filter)
+	}
+
+	containerInstanceArns := make([]string, 0)
+	for {
+		output, err := ecsHandler.ListContainerInstances(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		containerInstanceArns = append(containerInstanceArns, output.ContainerInstanceArns...)
+
+		if output.NextToken == nil {
+			break
+		}
+
+		input.NextToken = output.NextToken
+	}
+
+	return containerInstanceArns, nil
+}
+
+// describeContainerInstances describes the given container instances of the cluster in chunks accepted by the api
+func describeContainerInstances(ctx context.Context, cfg aws.Config, clusterName string, containerInstanceArns []string) ([]types.ContainerInstance, error) {
+	ecsHandler := ecsLib.NewFromConfig(cfg)
+
+	containerInstances := make([]types.ContainerInstance, 0)
+	for _, chunk := range utils.GetChunks(containerInstanceArns, 100) {
+		output, err := ecsHandler.DescribeContainerInstances(ctx, &ecsLib.DescribeContainerInstancesInput{
+			Cluster:            aws.String(clusterName),
+			ContainerInstances: chunk,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		containerInstances = append(containerInstances, output.ContainerInstances...)
+	}
+
+	return containerInstances, nil
+}
+
+// resolveContainerInstance finds the container instance of the cluster by its arn, id or ec2 instance id
+func resolveContainerInstance(ctx context.Context, cfg aws.Config, clusterName, instance string) (*types.ContainerInstance, error) {
+	containerInstanceArn := instance
+	if strings.HasPrefix(instance, "i-") {
+		arns, err := listContainerInstances(ctx, cfg, clusterName, "ec2InstanceId == "+instance)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(arns) == 0 {
+			return nil, errors.New("no container instance found for " + instance + " in cluster " + clusterName)
+		}
+
+		containerInstanceArn = arns[0]
+	}
+
+	containerInstances, err := describeContainerInstances(ctx, cfg, clusterName, []string{containerInstanceArn})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(containerInstances) == 0 {
+		return nil, errors.New("container instance " + instance + " not found in cluster " + clusterName)
+	}
+
+	return &containerInstances[0], nil
+}
+
+// mapContainerInstances describes the given container instances of the cluster along with their ec2 instances, keyed by arn
+func mapContainerInstances(ctx context.Context, cfg aws.Config, clusterName string, containerInstanceArns []string) (map[string]*ContainerInstance, error) {
+	containerInstancesMap := make(map[string]*ContainerInstance)
+	if len(containerInstanceArns) == 0 {
+		return containerInstancesMap, nil
+	}
+
+	containerInstances, err := describeContainerInstances(ctx, cfg, clusterName, containerInstanceArns)
+	if err != nil {
+		return nil, err
+	}
+
+	instanceIDsMap := make(map[string]ec2.Instance)
+	for _, containerInstance := range containerInstances {
+		instanceIDsMap[aws.ToString(containerInstance.Ec2InstanceId)] = ec2.Instance{
+			ID: aws.ToString(containerInstance.Ec2InstanceId),
+		}
+
+		containerInstancesMap[aws.ToString(containerInstance.ContainerInstanceArn)] = &ContainerInstance{
+			Arn:              containerInstance.ContainerInstanceArn,
+			Instance:         instanceIDsMap[aws.ToString(containerInstance.Ec2InstanceId)],
+			Status:           aws.ToString(containerInstance.Status),
+			AgentVersion:     agentVersion(containerInstance),
+			RunningTasks:     containerInstance.RunningTasksCount,
+			PendingTasks:     containerInstance.PendingTasksCount,
+			RegisteredCPU:    resourceValue(containerInstance.RegisteredResources, "CPU"),
+			RegisteredMemory: resourceValue(containerInstance.RegisteredResources, "MEMORY"),
+			RemainingCPU:     resourceValue(containerInstance.RemainingResources, "CPU"),
+			RemainingMemory:  resourceValue(containerInstance.RemainingResources, "MEMORY"),
+		}
+	}
+
+	instanceIDs := make([]string, 0)
+	for instanceID := range instanceIDsMap {
+		instanceIDs = append(instanceIDs, instanceID)
+	}
+
+	instancesDetails, err := ec2.DescribeInstances(ctx, cfg, instanceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, instancesDetail := range *instancesDetails {
+		instanceIDsMap[instancesDetail.ID] = instancesDetail
+	}
+
+	for _, containerInstance := range containerInstancesMap {
+		containerInstance.Instance = instanceIDsMap[containerInstance.Instance.ID]
+	}
+
+	return containerInstancesMap, nil
+}
+
+func resourceValue(resources []types.Resource, name string) int32 {
+	for _, resource := range resources {
+		if aws.ToString(resource.Name) == name {
+			return resource.IntegerValue
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,266 @@
+package ecs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"bitbucket.org/agrim123/onyx/pkg/logger"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+type ChangeType string
+
+const (
+	ChangeAdded    ChangeType = "+"
+	ChangeRemoved  ChangeType = "-"
+	ChangeModified ChangeType = "~"
+)
+
+// TaskDefinitionChange is a single semantic difference between two task definitions
+type TaskDefinitionChange struct {
+	Type      ChangeType
+	Container string
+	Field     string
+	Old       string
+	New       string
+}
+
+func (c *TaskDefinitionChange) String() string {
+	switch c.Type {
+	case ChangeAdded:
+		return logger.Green(fmt.Sprintf("  + %s: %s", c.Field, c.New))
+	case ChangeRemoved:
+		return logger.Red(fmt.Sprintf("  - %s: %s", c.Field, c.Old))
+	default:
+		return logger.Yellow(fmt.Sprintf("  ~ %s: %s -> %s", c.Field, c.Old, c.New))
+	}
+}
+
+// DiffTaskDefinitions compares task level settings and containers (matched by name) of two task definitions
+func DiffTaskDefinitions(a, b *types.TaskDefinition) []TaskDefinitionChange {
+	changes := make([]TaskDefinitionChange, 0)
+
+	changes = append(changes, diffValues("", "cpu", aws.ToString(a.Cpu), aws.ToString(b.Cpu))...)
+	changes = append(changes, diffValues("", "memory", aws.ToString(a.Memory), aws.ToString(b.Memory))...)
+	changes = append(changes, diffValues("", "network mode", string(a.NetworkMode), string(b.NetworkMode))...)
+	changes = append(changes, diffValues("", "task role", aws.ToString(a.TaskRoleArn), aws.ToString(b.TaskRoleArn))...)
+	changes = append(changes, diffValues("", "execution role", aws.ToString(a.ExecutionRoleArn), aws.ToString(b.ExecutionRoleArn))...)
+
+	containersA := make(map[string]types.ContainerDefinition)
+	imagesA := make(map[string]string)
+	for _, container := range a.ContainerDefinitions {
+		containersA[aws.ToString(container.Name)] = container
+		imagesA[aws.ToString(container.Name)] = aws.ToString(container.Image)
+	}
+
+	containersB := make(map[string]types.ContainerDefinition)
+	imagesB := make(map[string]string)
+	for _, container := range b.ContainerDefinitions {
+		containersB[aws.ToString(container.Name)] = container
+		imagesB[aws.ToString(container.Name)] = aws.ToString(container.Image)
+	}
+
+	for _, name := range sortedKeys(imagesA, imagesB) {
+		containerA, inA := containersA[name]
+		containerB, inB := containersB[name]
+
+		switch {
+		case !inA:
+			changes = append(changes, TaskDefinitionChange{Type: ChangeAdded, Container: name, Field: "container", New: aws.ToString(containerB.Image)})
+		case !inB:
+			changes = append(changes, TaskDefinitionChange{Type: ChangeRemoved, Container: name, Field: "container", Old: aws.ToString(containerA.Image)})
+		default:
+			changes = append(changes, diffContainers(name, containerA, containerB)...)
+		}
+	}
+
+	return changes
+}
+
+func diffContainers(name string, a, b types.ContainerDefinition) []TaskDefinitionChange {
+	changes := make([]TaskDefinitionChange, 0)
+
+	changes = append(changes, diffValues(name, "image", aws.ToString(a.Image), aws.ToString(b.Image))...)
+	changes = append(changes, diffValues(name, "cpu", int32ToString(&a.Cpu), int32ToString(&b.Cpu))...)
+	changes = append(changes, diffValues(name, "memory", int32ToString(a.Memory), int32ToString(b.Memory))...)
+	changes = append(changes, diffValues(name, "memory reservation", int32ToString(a.MemoryReservation), int32ToString(b.MemoryReservation))...)
+	changes = append(changes, diffValues(name, "command", strings.Join(a.Command, " "), strings.Join(b.Command, " "))...)
+	changes = append(changes, diffValues(name, "entrypoint", strings.Join(a.EntryPoint, " "), strings.Join(b.EntryPoint, " "))...)
+
+	envA := make(map[string]string)
+	for _, env := range a.Environment {
+		envA[aws.ToString(env.Name)] = aws.ToString(env.Value)
+	}
+	envB := make(map[string]string)
+	for _, env := range b.Environment {
+		envB[aws.ToString(env.Name)] = aws.ToString(env.Value)
+	}
+	changes = append(changes, diffMaps(name, "env", envA, envB)...)
+
+	secretsA := make(map[string]string)
+	for _, secret := range a.Secrets {
+		secretsA[aws.ToString(secret.Name)] = aws.ToString(secret.ValueFrom)
+	}
+	secretsB := make(map[string]string)
+	for _, secret := range b.Secrets {
+		secretsB[aws.ToString(secret.Name)] = aws.ToString(secret.ValueFrom)
+	}
+	changes = append(changes, diffMaps(name, "secret", secretsA, secretsB)...)
+
+	portsA := make(map[string]string)
+	for _, port := range a.PortMappings {
+		portsA[portMappingToString(port)] = portMappingToString(port)
+	}
+	portsB := make(map[string]string)
+	for _, port := range b.PortMappings {
+		portsB[portMappingToString(port)] = portMappingToString(port)
+	}
+	for _, key := range sortedKeys(portsA, portsB) {
+		changes = append(changes, diffValues(name, "port", portsA[key], portsB[key])...)
+	}
+
+	logA := make(map[string]string)
+	if a.LogConfiguration != nil {
+		logA["driver"] = string(a.LogConfiguration.LogDriver)
+		for key, value := range a.LogConfiguration.Options {
+			logA[key] = value
+		}
+	}
+	logB := make(map[string]string)
+	if b.LogConfiguration != nil {
+		logB["driver"] = string(b.LogConfiguration.LogDriver)
+		for key, value := range b.LogConfiguration.Options {
+			logB[key] = value
+		}
+	}
+	changes = append(changes, diffMaps(name, "log", logA, logB)...)
+
+	return changes
+}
+
+func diffValues(container, field, a, b string) []TaskDefinitionChange {
+	switch {
+	case a == b:
+		return nil
+	case a == "":
+		return []TaskDefinitionChange{{Type: ChangeAdded, Container: container, Field: field, New: b}}
+	case b == "":
+		return []TaskDefinitionChange{{Type: ChangeRemoved, Container: container, Field: field, Old: a}}
+	}
+
+	return []TaskDefinitionChange{{Type: ChangeModified, Container: container, Field: field, Old: a, New: b}}
+}
+
+func diffMaps(container, field string, a, b map[string]string) []TaskDefinitionChange {
+	changes := make([]TaskDefinitionChange, 0)
+	for _, key := range sortedKeys(a, b) {
+		valueA, inA := a[key]
+		valueB, inB := b[key]
+
+		switch {
+		case !inA:
+			changes = append(changes, TaskDefinitionChange{Type: ChangeAdded, Container: container, Field: field + " " + key, New: valueB})
+		case !inB:
+			changes = append(changes, TaskDefinitionChange{Type: ChangeRemoved, Container: container, Field: field + " " + key, Old: valueA})
+		case valueA != valueB:
+			changes = append(changes, TaskDefinitionChange{Type: ChangeModified, Container: container, Field: field + " " + key, Old: valueA, New: valueB})
+		}
+	}
+
+	return changes
+}
+
+func sortedKeys(a, b map[string]string) []string {
+	keysMap := make(map[string]bool)
+	for key := range a {
+		keysMap[key] = true
+	}
+	for key := range b {
+		keysMap[key] = true
+	}
+
+	keys := make([]string, 0)
+	for key := range keysMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func int32ToString(value *int32) string {
+	if value == nil || *value == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%d", *value)
+}
+
+func portMappingToString(port types.PortMapping) string {
+	return fmt.Sprintf("%s->%s/%s", int32ToString(port.HostPort), int32ToString(port.ContainerPort), port.Protocol)
+}
+
+// DiffTaskDefinitionRevisions prints the diff between two task definitions given as arn or `family:revision`
+func DiffTaskDefinitionRevisions(ctx context.Context, cfg aws.Config, a, b string) error {
+	taskDefinitionA, err := DescribeTaskDefinition(ctx, cfg, a)
+	if err != nil {
+		return err
+	}
+
+	taskDefinitionB, err := DescribeTaskDefinition(ctx, cfg, b)
+	if err != nil {
+		return err
+	}
+
+	printTaskDefinitionDiff(taskDefinitionA, taskDefinitionB)
+	return nil
+}
+
+// DiffServiceTaskDefinition prints the diff between the previous and current task definition revision of a service
+func DiffServiceTaskDefinition(ctx context.Context, cfg aws.Config, clusterName, serviceName string) error {
+	service, err := DescribeService(ctx, cfg, clusterName, serviceName)
+	if err != nil {
+		return err
+	}
+
+	family, currentRevision := parseTaskDefinitionArn(aws.ToString(service.TaskDefinition))
+
+	previousRevision, err := previousTaskDefinitionRevision(ctx, cfg, family, currentRevision)
+	if err != nil {
+		return err
+	}
+
+	if previousRevision == 0 {
+		return errors.New("no previous revision found for " + family)
+	}
+
+	return DiffTaskDefinitionRevisions(ctx, cfg, fmt.Sprintf("%s:%d", family, previousRevision), aws.ToString(service.TaskDefinition))
+}
+
+func printTaskDefinitionDiff(a, b *types.TaskDefinition) {
+	logger.Info("Comparing %s:%d -> %s:%d", aws.ToString(a.Family), a.Revision, aws.ToString(b.Family), b.Revision)
+
+	changes := DiffTaskDefinitions(a, b)
+	if len(changes) == 0 {
+		logger.Success("No differences")
+		return
+	}
+
+	container := "-"
+	for _, change := range changes {
+		if change.Container != container {
+			container = change.Container
+			if container == "" {
+				fmt.Println(logger.Bold("Task:"))
+			} else {
+				fmt.Println(logger.Bold("Container " + container + ":"))
+			}
+		}
+
+		fmt.Println(change.String())
+	}
+}
@@ -0,0 +1,274 @@
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"bitbucket.org/agrim123/onyx/pkg/core/ec2"
+	"bitbucket.org/agrim123/onyx/pkg/logger"
+	"bitbucket.org/agrim123/onyx/pkg/utils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecsLib "github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+const (
+	drainPollInterval = 10 * time.Second
+	drainTimeout      = 30 * time.Minute
+)
+
+// DrainContainerInstance sets the container instance to DRAINING and waits until its service tasks are rescheduled and
+// the services are back to their desired count. Standalone tasks, which DRAINING does not move, are listed and either
+// stopped or waited for. If terminate is set, the underlying ec2 instance is terminated afterwards, after confirmation.
+func DrainContainerInstance(ctx context.Context, cfg aws.Config, clusterName, instance string, terminate bool) error {
+	containerInstance, err := resolveContainerInstance(ctx, cfg, clusterName, instance)
+	if err != nil {
+		return err
+	}
+
+	instanceID := aws.ToString(containerInstance.Ec2InstanceId)
+
+	// Asked before draining so the answer is not awaited after a long drain
+	if terminate {
+		fmt.Println("Cluster Name:", clusterName)
+		fmt.Println("Container instance:", aws.ToString(containerInstance.ContainerInstanceArn))
+		confirmation := strings.TrimSpace(utils.GetUserInput(fmt.Sprintf("Drain and then terminate ec2 instance %s? [y/N]: ", instanceID)))
+		if confirmation != "y" && confirmation != "Y" {
+			logger.Info("Aborted")
+			return nil
+		}
+	}
+
+	if aws.ToString(containerInstance.Status) != string(types.ContainerInstanceStatusDraining) {
+		ecsHandler := ecsLib.NewFromConfig(cfg)
+		output, err := ecsHandler.UpdateContainerInstancesState(ctx, &ecsLib.UpdateContainerInstancesStateInput{
+			Cluster:            aws.String(clusterName),
+			ContainerInstances: []string{aws.ToString(containerInstance.ContainerInstanceArn)},
+			Status:             types.ContainerInstanceStatusDraining,
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(output.Failures) > 0 {
+			return fmt.Errorf("unable to drain %s. Reason: %s", instanceID, aws.ToString(output.Failures[0].Reason))
+		}
+
+		logger.Success("Set %s (%s) to DRAINING", logger.Bold(instanceID), aws.ToString(containerInstance.ContainerInstanceArn))
+	} else {
+		logger.Info("%s is already DRAINING", logger.Bold(instanceID))
+	}
+
+	// Only service tasks are moved by DRAINING, standalone tasks run on until they finish
+	tasks, err := listContainerInstanceTasks(ctx, cfg, clusterName, aws.ToString(containerInstance.ContainerInstanceArn))
+	if err != nil {
+		return err
+	}
+
+	services := make(map[string]bool)
+	for _, task := range tasks {
+		if name, ok := taskServiceName(task); ok {
+			services[name] = true
+		}
+	}
+
+	start := time.Now()
+	for {
+		serviceTasks, standaloneTasks := 0, 0
+		for _, task := range tasks {
+			if _, ok := taskServiceName(task); ok {
+				serviceTasks++
+			} else {
+				standaloneTasks++
+			}
+		}
+
+		fmt.Printf("\r  Waiting for service tasks to move off %s | service tasks: %d | standalone tasks: %d | elapsed: %s   ",
+			instanceID, serviceTasks, standaloneTasks, time.Since(start).Round(time.Second))
+
+		if serviceTasks == 0 {
+			fmt.Println()
+			break
+		}
+
+		if time.Since(start) > drainTimeout {
+			fmt.Println()
+			return fmt.Errorf("timed out after %s waiting for %s to drain", drainTimeout, instanceID)
+		}
+
+		time.Sleep(drainPollInterval)
+
+		tasks, err = listContainerInstanceTasks(ctx, cfg, clusterName, aws.ToString(containerInstance.ContainerInstanceArn))
+		if err != nil {
+			fmt.Println()
+			return err
+		}
+	}
+
+	if len(tasks) > 0 {
+		fmt.Println("Standalone tasks still running on", instanceID+":")
+		for _, task := range tasks {
+			family, revision := parseTaskDefinitionArn(aws.ToString(task.TaskDefinitionArn))
+			fmt.Printf("    %s %s:%d (%s, started by %s)\n", extractTaskID(aws.ToString(task.TaskArn)), family, revision, aws.ToString(task.LastStatus), aws.ToString(task.StartedBy))
+		}
+
+		choice := strings.ToLower(strings.TrimSpace(utils.GetUserInput("[s]top them, [w]ait for them to finish, or leave the instance as is [s/w/N]: ")))
+		switch choice {
+		case "s":
+			if err := stopTasks(ctx, cfg, clusterName, tasks); err != nil {
+				return err
+			}
+		case "w":
+		default:
+			logger.Info("Left %s DRAINING with %d standalone task(s)", logger.Bold(instanceID), len(tasks))
+			if terminate {
+				logger.Info("Not terminating %s", logger.Bold(instanceID))
+			}
+			return nil
+		}
+
+		if err := waitForNoTasks(ctx, cfg, clusterName, containerInstance, start); err != nil {
+			return err
+		}
+	}
+
+	for name := range services {
+		if err := waitForServiceRunning(ctx, cfg, clusterName, name, start); err != nil {
+			return err
+		}
+	}
+
+	logger.Success("Drained %s", logger.Bold(instanceID))
+
+	if !terminate {
+		return nil
+	}
+
+	logger.Info("Terminating %s. If it belongs to an auto scaling group, a replacement will be launched.", logger.Bold(instanceID))
+
+	return ec2.TerminateInstance(ctx, cfg, instanceID)
+}
+
+// taskServiceName returns the service which started the task, false for standalone tasks
+func taskServiceName(task types.Task) (string, bool) {
+	group := aws.ToString(task.Group)
+	if strings.HasPrefix(group, "service:") {
+		return strings.TrimPrefix(group, "service:"), true
+	}
+
+	return "", strings.HasPrefix(aws.ToString(task.StartedBy), "ecs-svc/")
+}
+
+// listContainerInstanceTasks returns the running and pending tasks placed on the container instance
+func listContainerInstanceTasks(ctx context.Context, cfg aws.Config, clusterName, containerInstanceArn string) ([]types.Task, error) {
+	ecsHandler := ecsLib.NewFromConfig(cfg)
+
+	arns := make([]string, 0)
+
+	var nextToken *string
+	for {
+		output, err := ecsHandler.ListTasks(ctx, &ecsLib.ListTasksInput{
+			Cluster:           aws.String(clusterName),
+			ContainerInstance: aws.String(containerInstanceArn),
+			NextToken:         nextToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		arns = append(arns, output.TaskArns...)
+
+		if output.NextToken == nil {
+			break
+		}
+
+		nextToken = output.NextToken
+	}
+
+	tasks := make([]types.Task, 0)
+	for _, chunk := range utils.GetChunks(arns, 100) {
+		output, err := ecsHandler.DescribeTasks(ctx, &ecsLib.DescribeTasksInput{
+			Cluster: aws.String(clusterName),
+			Tasks:   chunk,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, task := range output.Tasks {
+			if aws.ToString(task.LastStatus) != "STOPPED" {
+				tasks = append(tasks, task)
+			}
+		}
+	}
+
+	return tasks, nil
+}
+
+func stopTasks(ctx context.Context, cfg aws.Config, clusterName string, tasks []types.Task) error {
+	ecsHandler := ecsLib.NewFromConfig(cfg)
+	for _, task := range tasks {
+		_, err := ecsHandler.StopTask(ctx, &ecsLib.StopTaskInput{
+			Cluster: aws.String(clusterName),
+			Task:    task.TaskArn,
+			Reason:  aws.String("Stopped by onyx to drain the container instance"),
+		})
+		if err != nil {
+			return err
+		}
+
+		logger.Info("Stopping task %s", logger.Bold(extractTaskID(aws.ToString(task.TaskArn))))
+	}
+
+	return nil
+}
+
+// waitForNoTasks waits until no task is left on the container instance, within the drain timeout counted from start
+func waitForNoTasks(ctx context.Context, cfg aws.Config, clusterName string, containerInstance *types.ContainerInstance, start time.Time) error {
+	instanceID := aws.ToString(containerInstance.Ec2InstanceId)
+	for {
+		tasks, err := listContainerInstanceTasks(ctx, cfg, clusterName, aws.ToString(containerInstance.ContainerInstanceArn))
+		if err != nil {
+			return err
+		}
+
+		if len(tasks) == 0 {
+			return nil
+		}
+
+		if time.Since(start) > drainTimeout {
+			return fmt.Errorf("timed out after %s waiting for %d task(s) on %s to stop", drainTimeout, len(tasks), instanceID)
+		}
+
+		fmt.Printf("  Waiting for %d task(s) on %s to stop\n", len(tasks), instanceID)
+		time.Sleep(drainPollInterval)
+	}
+}
+
+// waitForServiceRunning waits until the service runs its desired count again, within the drain timeout counted from start
+func waitForServiceRunning(ctx context.Context, cfg aws.Config, clusterName, serviceName string, start time.Time) error {
+	for {
+		service, err := describeServiceByName(ctx, cfg, clusterName, serviceName)
+		if err != nil {
+			return err
+		}
+
+		if service == nil {
+			return fmt.Errorf("service %s not found in cluster %s", serviceName, clusterName)
+		}
+
+		if service.RunningCount == service.DesiredCount {
+			logger.Success("%s is running %d/%d tasks", logger.Bold(serviceName), service.RunningCount, service.DesiredCount)
+			return nil
+		}
+
+		if time.Since(start) > drainTimeout {
+			return fmt.Errorf("timed out after %s waiting for %s to run its desired count, running %d/%d", drainTimeout, serviceName, service.RunningCount, service.DesiredCount)
+		}
+
+		fmt.Printf("  Waiting for %s to be rescheduled | running: %d | desired: %d\n", serviceName, service.RunningCount, service.DesiredCount)
+		time.Sleep(drainPollInterval)
+	}
+}
@@ -4,44 +4,53 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strconv"
-	"strings"
 
 	"bitbucket.org/agrim123/onyx/pkg/core/ec2"
+	"bitbucket.org/agrim123/onyx/pkg/core/iam"
 	"bitbucket.org/agrim123/onyx/pkg/logger"
-	"bitbucket.org/agrim123/onyx/pkg/utils"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	ecsLib "github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
 )
 
 type ContainerInstance struct {
-	Arn      *string
-	Instance ec2.Instance
+	Arn              *string
+	Instance         ec2.Instance
+	Status           string
+	AgentVersion     string
+	RunningTasks     int32
+	PendingTasks     int32
+	RegisteredCPU    int32
+	RegisteredMemory int32
+	RemainingCPU     int32
+	RemainingMemory  int32
 }
 
-func Describe(ctx context.Context, cfg aws.Config, serviceName, nameFilter string) error {
+func Describe(ctx context.Context, cfg aws.Config, serviceName, nameFilter string, strategy types.SchedulingStrategy) error {
 	clusters, err := ListClusters(ctx, cfg, nameFilter)
 	if err != nil {
 		return err
 	}
 
 	for _, cluster := range *clusters {
-		DescribeByCluster(ctx, cfg, cluster.Name, serviceName)
+		if err := DescribeByCluster(ctx, cfg, cluster.Name, serviceName, strategy); err != nil {
+			logger.Error("Unable to describe cluster %s. Error: %s", logger.Underline(cluster.Name), err.Error())
+		}
 	}
 
 	return nil
 }
 
-func DescribeByCluster(ctx context.Context, cfg aws.Config, clusterName, serviceName string) error {
+func DescribeByCluster(ctx context.Context, cfg aws.Config, clusterName, serviceName string, strategy types.SchedulingStrategy) error {
 	if serviceName == "" {
 		logger.Warn("Service name is not provided. This results in large query, please consider narrowing your search.")
 	}
 
 	cluster := Cluster{
-		Name: clusterName,
+		Name:               clusterName,
+		SchedulingStrategy: strategy,
 	}
 
-	ecsHandler := ecsLib.NewFromConfig(cfg)
 	// Fetch all services of the cluster
 	err := cluster.GetServices(ctx, cfg, serviceName)
 	if err != nil {
@@ -66,46 +75,13 @@ func DescribeByCluster(ctx context.Context, cfg aws.Config, clusterName, service
 		containerInstancesArns = append(containerInstancesArns, containerInstanceArn)
 	}
 
-	// Get the required container instances filteres from tasks in a cluster
-	containerInstances, err := ecsHandler.DescribeContainerInstances(ctx, &ecsLib.DescribeContainerInstancesInput{
-		ContainerInstances: containerInstancesArns,
-		Cluster:            &clusterName,
-	})
-
-	if err != nil {
-		return err
-	}
-
-	instanceIDsMap := make(map[string]ec2.Instance)
-	for _, containerInstance := range containerInstances.ContainerInstances {
-		instanceIDsMap[*containerInstance.Ec2InstanceId] = ec2.Instance{
-			ID: *containerInstance.Ec2InstanceId,
-		}
-
-		containerInstancesMap[*containerInstance.ContainerInstanceArn] = &ContainerInstance{
-			Arn:      containerInstance.ContainerInstanceArn,
-			Instance: instanceIDsMap[*containerInstance.Ec2InstanceId],
-		}
-	}
-
-	instanceIDs := make([]string, 0)
-	for instanceID := range instanceIDsMap {
-		instanceIDs = append(instanceIDs, instanceID)
-	}
-
-	instancesDetails, err := ec2.DescribeInstances(ctx, cfg, instanceIDs)
+	// Get the required container instances filtered from tasks in a cluster, along with their ec2 instances
+	describedContainerInstances, err := mapContainerInstances(ctx, cfg, clusterName, containerInstancesArns)
 	if err != nil {
 		return err
 	}
 
-	for _, instancesDetail := range *instancesDetails {
-		instanceIDsMap[instancesDetail.ID] = instancesDetail
-	}
-
-	for containerInstanceArn, containerInstance := range containerInstancesMap {
-		instanceID := containerInstance.Instance.ID
-
-		containerInstance.Instance = instanceIDsMap[instanceID]
+	for containerInstanceArn, containerInstance := range describedContainerInstances {
 		containerInstancesMap[containerInstanceArn] = containerInstance
 	}
 
@@ -114,18 +90,14 @@ func DescribeByCluster(ctx context.Context, cfg aws.Config, clusterName, service
 		(*allTasks)[taskArn] = task
 	}
 
-	taskPerTaskDefinition := make(map[string][]Task)
+	taskPerService := make(map[string][]Task)
 	for _, task := range *allTasks {
-		if tasks, ok := taskPerTaskDefinition[task.TaskDefinitionArn]; ok {
-			taskPerTaskDefinition[task.TaskDefinitionArn] = append(tasks, task)
-		} else {
-			taskPerTaskDefinition[task.TaskDefinitionArn] = []Task{task}
-		}
+		taskPerService[task.Service.Name] = append(taskPerService[task.Service.Name], task)
 	}
 
 	allServices := &cluster.Services
 	for i, service := range *allServices {
-		service.Tasks = taskPerTaskDefinition[service.TaskDefinitionArn]
+		service.Tasks = taskPerService[service.Name]
 		(*allServices)[i] = service
 	}
 
@@ -148,44 +120,37 @@ func DescribeByCluster(ctx context.Context, cfg aws.Config, clusterName, service
 	return nil
 }
 
-func RedeployService(ctx context.Context, cfg aws.Config, clusterName, serviceName string) error {
+func RedeployService(ctx context.Context, cfg aws.Config, clusterName, serviceName string, strategy types.SchedulingStrategy) error {
 	cluster := Cluster{
-		Name: clusterName,
+		Name:               clusterName,
+		SchedulingStrategy: strategy,
 	}
 
-	serviceMap := make(map[string]bool)
 	err := cluster.GetServices(ctx, cfg, serviceName)
 	if err != nil {
 		return err
 	}
 
-	cluster.FilterServicesByName(serviceName)
-
-	fmt.Println("Cluster Name:", clusterName)
-	fmt.Println("Select service(s) to restart:")
-	for i, service := range cluster.Services {
-		fmt.Println(logger.Bold(i), ":", service.Name)
-	}
-
-	indexes := utils.GetUserInput("Enter choice: ")
-	if len(indexes) == 0 {
-		return errors.New("invalid choice")
-	}
-
-	for _, index := range strings.Split(indexes, ",") {
-		i, _ := strconv.ParseInt(strings.TrimSpace(index), 0, 32)
-		serviceMap[cluster.Services[int(i)].Name] = true
+	selectedServices, err := cluster.SelectServices("Select service(s) to restart:")
+	if err != nil {
+		return err
 	}
 
 	services := make([]string, 0)
-	for service := range serviceMap {
-		services = append(services, service)
+	serviceArns := make([]string, 0)
+	for _, service := range selectedServices {
+		services = append(services, service.Name)
+		serviceArns = append(serviceArns, aws.ToString(service.Arn))
 	}
 
 	if len(services) == 0 {
 		return errors.New("no services to restart")
 	}
 
+	if err := iam.Preflight(ctx, cfg, []string{"ecs:UpdateService"}, serviceArns); err != nil {
+		return err
+	}
+
 	for _, service := range services {
 		ecsHandler := ecsLib.NewFromConfig(cfg)
 		_, err := ecsHandler.UpdateService(ctx, &ecsLib.UpdateServiceInput{
@@ -203,39 +168,3 @@ func RedeployService(ctx context.Context, cfg aws.Config, clusterName, serviceNa
 
 	return nil
 }
-
-func UpdateContainerAgent(ctx context.Context, cfg aws.Config) error {
-	ecsHandler := ecsLib.NewFromConfig(cfg)
-	clusterOutput, err := ecsHandler.ListClusters(ctx, &ecsLib.ListClustersInput{})
-	if err != nil {
-		return err
-	}
-
-	containerInstances := make(map[string][]string)
-	for _, cluster := range clusterOutput.ClusterArns {
-		containerInstancesOutput, err := ecsHandler.ListContainerInstances(ctx, &ecsLib.ListContainerInstancesInput{
-			Cluster: aws.String(cluster),
-		})
-		if err != nil {
-			logger.Error("Unable to get container instances for cluster %s. Error: %s", logger.Underline(cluster), err.Error())
-			continue
-		}
-
-		containerInstances[cluster] = containerInstancesOutput.ContainerInstanceArns
-	}
-
-	for cluster, containerInstances := range containerInstances {
-		for _, containerInstance := range containerInstances {
-			_, err := ecsHandler.UpdateContainerAgent(ctx, &ecsLib.UpdateContainerAgentInput{
-				Cluster:           aws.String(cluster),
-				ContainerInstance: aws.String(containerInstance),
-			})
-			if err != nil {
-				logger.Error("Unable to update container agent for cluster %s and container instance %s. Error: %s", logger.Underline(cluster), logger.Underline(containerInstance), err.Error())
-				continue
-			}
-		}
-	}
-
-	return nil
-}
@@ -0,0 +1,186 @@
+package ecs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"bitbucket.org/agrim123/onyx/pkg/logger"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// ServiceEnvironment prints environment variables, environment files and secret references of the containers of the
+// service's current task definition. With resolve, secret values are fetched and printed masked unless show is set.
+func ServiceEnvironment(ctx context.Context, cfg aws.Config, clusterName, serviceName, containerName string, resolve, show bool) error {
+	service, err := DescribeService(ctx, cfg, clusterName, serviceName)
+	if err != nil {
+		return err
+	}
+
+	taskDefinition, err := DescribeTaskDefinition(ctx, cfg, aws.ToString(service.TaskDefinition))
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Service Name:", aws.ToString(service.ServiceName))
+	fmt.Println("Task Definition:", aws.ToString(taskDefinition.TaskDefinitionArn))
+
+	found := false
+	for _, container := range taskDefinition.ContainerDefinitions {
+		if containerName != "" && aws.ToString(container.Name) != containerName {
+			continue
+		}
+		found = true
+
+		fmt.Println(logger.Bold("Container " + aws.ToString(container.Name) + ":"))
+		printContainerEnvironment(ctx, cfg, container, resolve, show)
+	}
+
+	if !found {
+		return errors.New("container " + containerName + " not found in " + aws.ToString(taskDefinition.TaskDefinitionArn))
+	}
+
+	if resolve {
+		logger.Warn("Secrets are resolved with your credentials, not the task's execution role")
+	}
+
+	return nil
+}
+
+func printContainerEnvironment(ctx context.Context, cfg aws.Config, container types.ContainerDefinition, resolve, show bool) {
+	environment := make([]string, 0)
+	for _, env := range container.Environment {
+		environment = append(environment, aws.ToString(env.Name)+"="+aws.ToString(env.Value))
+	}
+	sort.Strings(environment)
+
+	fmt.Println("  Environment:")
+	for _, env := range environment {
+		fmt.Println("   ", env)
+	}
+
+	if len(container.EnvironmentFiles) > 0 {
+		fmt.Println("  Environment files:")
+		for _, file := range container.EnvironmentFiles {
+			fmt.Printf("    %s (%s)\n", aws.ToString(file.Value), file.Type)
+		}
+	}
+
+	secrets := make([]types.Secret, len(container.Secrets))
+	copy(secrets, container.Secrets)
+	sort.Slice(secrets, func(i, j int) bool {
+		return aws.ToString(secrets[i].Name) < aws.ToString(secrets[j].Name)
+	})
+
+	fmt.Println("  Secrets:")
+	for _, secret := range secrets {
+		valueFrom := aws.ToString(secret.ValueFrom)
+
+		source := "SSM parameter"
+		if strings.HasPrefix(valueFrom, "arn:aws:secretsmanager:") {
+			source = "Secrets Manager"
+		}
+
+		line := fmt.Sprintf("    %s <- %s (%s)", aws.ToString(secret.Name), valueFrom, source)
+		if resolve {
+			value, err := resolveSecret(ctx, cfg, valueFrom)
+			switch {
+			case err != nil:
+				line += " = " + logger.Red("unable to resolve: "+err.Error())
+			case show:
+				line += " = " + value
+			default:
+				line += " = " + maskSecret(value)
+			}
+		}
+
+		fmt.Println(line)
+	}
+}
+
+// resolveSecret fetches the value a task definition secret points to. Secrets Manager references can be of the form
+// arn:aws:secretsmanager:region:account:secret:name[:json-key:version-stage:version-id], anything else is an SSM parameter.
+func resolveSecret(ctx context.Context, cfg aws.Config, valueFrom string) (string, error) {
+	parts := strings.Split(valueFrom, ":")
+
+	// Referenced resources may live in another region
+	if strings.HasPrefix(valueFrom, "arn:") && len(parts) > 3 && parts[3] != "" {
+		cfg = cfg.Copy()
+		cfg.Region = parts[3]
+	}
+
+	if !strings.HasPrefix(valueFrom, "arn:aws:secretsmanager:") {
+		ssmHandler := ssm.NewFromConfig(cfg)
+		output, err := ssmHandler.GetParameter(ctx, &ssm.GetParameterInput{
+			Name:           aws.String(valueFrom),
+			WithDecryption: true,
+		})
+		if err != nil {
+			return "", err
+		}
+
+		return aws.ToString(output.Parameter.Value), nil
+	}
+
+	if len(parts) < 7 {
+		return "", errors.New("invalid secrets manager reference")
+	}
+
+	input := &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(strings.Join(parts[:7], ":")),
+	}
+
+	jsonKey := ""
+	if len(parts) > 7 {
+		jsonKey = parts[7]
+	}
+	if len(parts) > 8 && parts[8] != "" {
+		input.VersionStage = aws.String(parts[8])
+	}
+	if len(parts) > 9 && parts[9] != "" {
+		input.VersionId = aws.String(parts[9])
+	}
+
+	secretsmanagerHandler := secretsmanager.NewFromConfig(cfg)
+	output, err := secretsmanagerHandler.GetSecretValue(ctx, input)
+	if err != nil {
+		return "", err
+	}
+
+	value := aws.ToString(output.SecretString)
+	if jsonKey == "" {
+		return value, nil
+	}
+
+	keys := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(value), &keys); err != nil {
+		return "", errors.New("secret is not a JSON object, unable to read key " + jsonKey)
+	}
+
+	keyValue, ok := keys[jsonKey]
+	if !ok {
+		return "", errors.New("key " + jsonKey + " not found in secret")
+	}
+
+	if s, ok := keyValue.(string); ok {
+		return s, nil
+	}
+
+	b, err := json.Marshal(keyValue)
+	return string(b), err
+}
+
+// maskSecret hides the value, including its length, only telling whether it is empty
+func maskSecret(value string) string {
+	if value == "" {
+		return "(empty)"
+	}
+
+	return "********"
+}
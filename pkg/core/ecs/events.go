@@ -0,0 +1,175 @@
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"bitbucket.org/agrim123/onyx/pkg/logger"
+	"bitbucket.org/agrim123/onyx/pkg/utils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecsLib "github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+const timeFormat = "2006-01-02 15:04:05"
+
+// Known failure signatures of stopped tasks and containers with a short explanation
+var stopDiagnoses = []struct {
+	pattern   string
+	diagnosis string
+}{
+	{"OutOfMemory", "OOM: container exceeded its memory limit"},
+	{"CannotPullContainerError", "image pull failed: check the image tag, registry access and execution role"},
+	{"pull image", "image pull failed: check the image tag, registry access and execution role"},
+	{"ResourceInitializationError", "task resources could not be initialized: check secrets, execution role and network access"},
+	{"health checks", "health check failure"},
+	{"HealthCheck", "health check failure"},
+	{"CannotStartContainerError", "container could not be started: check command, entrypoint and volumes"},
+	{"Scaling activity", "stopped by service scaling"},
+	{"deployment", "replaced by a deployment"},
+}
+
+// Exit codes worth explaining when no reason is given
+var exitCodeDiagnoses = map[int32]string{
+	1:   "application error",
+	137: "killed (SIGKILL): OOM or stop timeout exceeded",
+	139: "segmentation fault",
+	143: "terminated (SIGTERM)",
+}
+
+// ServiceEvents prints the most recent events of the service
+func ServiceEvents(ctx context.Context, cfg aws.Config, clusterName, serviceName string, limit int) error {
+	service, err := DescribeService(ctx, cfg, clusterName, serviceName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Service Name:", aws.ToString(service.ServiceName))
+	fmt.Printf("Desired: %d | Running: %d | Pending: %d\n", service.DesiredCount, service.RunningCount, service.PendingCount)
+	fmt.Println("Events:")
+	for i, event := range service.Events {
+		if i >= limit {
+			break
+		}
+
+		fmt.Println(" ", formatTime(event.CreatedAt), aws.ToString(event.Message))
+	}
+
+	return nil
+}
+
+// StoppedTasks prints recently stopped tasks of the service with stop codes, reasons and container exit codes
+func StoppedTasks(ctx context.Context, cfg aws.Config, clusterName, serviceName string, limit int) error {
+	service, err := DescribeService(ctx, cfg, clusterName, serviceName)
+	if err != nil {
+		return err
+	}
+
+	ecsHandler := ecsLib.NewFromConfig(cfg)
+
+	taskArns := make([]string, 0)
+	var nextToken *string
+	for {
+		output, err := ecsHandler.ListTasks(ctx, &ecsLib.ListTasksInput{
+			Cluster:       aws.String(clusterName),
+			ServiceName:   service.ServiceName,
+			DesiredStatus: types.DesiredStatusStopped,
+			NextToken:     nextToken,
+		})
+		if err != nil {
+			return err
+		}
+
+		taskArns = append(taskArns, output.TaskArns...)
+
+		if output.NextToken == nil {
+			break
+		}
+
+		nextToken = output.NextToken
+	}
+
+	if len(taskArns) == 0 {
+		logger.Info("No recently stopped tasks for %s", logger.Bold(serviceName))
+		return nil
+	}
+
+	tasks := make([]types.Task, 0)
+	for _, chunk := range utils.GetChunks(taskArns, 100) {
+		output, err := ecsHandler.DescribeTasks(ctx, &ecsLib.DescribeTasksInput{
+			Cluster: aws.String(clusterName),
+			Tasks:   chunk,
+		})
+		if err != nil {
+			return err
+		}
+
+		tasks = append(tasks, output.Tasks...)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return aws.ToTime(tasks[i].StoppedAt).After(aws.ToTime(tasks[j].StoppedAt))
+	})
+
+	fmt.Println("Service Name:", aws.ToString(service.ServiceName))
+	fmt.Println("Stopped tasks:")
+	for i, task := range tasks {
+		if i >= limit {
+			break
+		}
+
+		family, revision := parseTaskDefinitionArn(aws.ToString(task.TaskDefinitionArn))
+		fmt.Printf("%s (%s:%d)\n", logger.Bold(extractTaskID(aws.ToString(task.TaskArn))), family, revision)
+		fmt.Println("  Stopped at:", formatTime(task.StoppedAt))
+		fmt.Println("  Stop code: ", task.StopCode)
+		fmt.Println("  Reason:    ", aws.ToString(task.StoppedReason))
+		if diagnosis := diagnose(aws.ToString(task.StoppedReason), nil); diagnosis != "" {
+			fmt.Println("  Diagnosis: ", logger.Red(diagnosis))
+		}
+
+		fmt.Println("  Containers:")
+		for _, container := range task.Containers {
+			exitCode := "-"
+			if container.ExitCode != nil {
+				exitCode = fmt.Sprintf("%d", *container.ExitCode)
+			}
+
+			line := fmt.Sprintf("    %s: exit code %s", aws.ToString(container.Name), exitCode)
+			if reason := aws.ToString(container.Reason); reason != "" {
+				line += " | " + reason
+			}
+			if diagnosis := diagnose(aws.ToString(container.Reason), container.ExitCode); diagnosis != "" {
+				line += " | " + logger.Red(diagnosis)
+			}
+
+			fmt.Println(line)
+		}
+	}
+
+	return nil
+}
+
+func diagnose(reason string, exitCode *int32) string {
+	for _, d := range stopDiagnoses {
+		if strings.Contains(reason, d.pattern) {
+			return d.diagnosis
+		}
+	}
+
+	if exitCode != nil {
+		return exitCodeDiagnoses[*exitCode]
+	}
+
+	return ""
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+
+	return t.Local().Format(timeFormat)
+}
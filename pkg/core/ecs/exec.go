@@ -0,0 +1,198 @@
+package ecs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+
+	"bitbucket.org/agrim123/onyx/pkg/core/iam"
+	"bitbucket.org/agrim123/onyx/pkg/logger"
+	"bitbucket.org/agrim123/onyx/pkg/utils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecsLib "github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+const sessionManagerPlugin = "session-manager-plugin"
+
+// Actions the task role needs for the ssm agent inside the container to open a session
+var execTaskRoleActions = []string{
+	"ssmmessages:CreateControlChannel",
+	"ssmmessages:CreateDataChannel",
+	"ssmmessages:OpenControlChannel",
+	"ssmmessages:OpenDataChannel",
+}
+
+// ExecuteCommand opens an interactive session running command in a container of a running task of the chosen service
+func ExecuteCommand(ctx context.Context, cfg aws.Config, clusterName, serviceName, containerName string, command []string) error {
+	if _, err := exec.LookPath(sessionManagerPlugin); err != nil {
+		return errors.New("session-manager-plugin not found in PATH. Install it from https://docs.aws.amazon.com/systems-manager/latest/userguide/session-manager-working-with-install-plugin.html")
+	}
+
+	cluster := Cluster{
+		Name: clusterName,
+	}
+
+	err := cluster.GetServices(ctx, cfg, serviceName)
+	if err != nil {
+		return err
+	}
+
+	// No need to ask when the name matches a single service
+	services := cluster.Services
+	if len(services) != 1 {
+		services, err = cluster.SelectServices("Select service to exec into:")
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(services) != 1 {
+		return errors.New("select exactly one service")
+	}
+
+	task, container, err := execPreflight(ctx, cfg, clusterName, services[0].Name, containerName)
+	if err != nil {
+		return err
+	}
+
+	ecsHandler := ecsLib.NewFromConfig(cfg)
+	output, err := ecsHandler.ExecuteCommand(ctx, &ecsLib.ExecuteCommandInput{
+		Cluster:     aws.String(clusterName),
+		Task:        task.TaskArn,
+		Container:   container.Name,
+		Command:     aws.String(utils.JoinCommand(command)),
+		Interactive: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	session, err := json.Marshal(output.Session)
+	if err != nil {
+		return err
+	}
+
+	target, err := json.Marshal(map[string]string{
+		"Target": fmt.Sprintf("ecs:%s_%s_%s", clusterName, extractTaskID(aws.ToString(task.TaskArn)), aws.ToString(container.RuntimeId)),
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Starting session in %s of task %s", logger.Bold(aws.ToString(container.Name)), extractTaskID(aws.ToString(task.TaskArn)))
+
+	// Interrupts belong to the remote shell, the plugin handles them
+	signal.Ignore(os.Interrupt)
+	defer signal.Reset(os.Interrupt)
+
+	plugin := exec.Command(sessionManagerPlugin, string(session), cfg.Region, "StartSession", "", string(target), fmt.Sprintf("https://ecs.%s.amazonaws.com", cfg.Region))
+	plugin.Stdin = os.Stdin
+	plugin.Stdout = os.Stdout
+	plugin.Stderr = os.Stderr
+
+	return plugin.Run()
+}
+
+// execPreflight verifies the service, its task role and a running task are ready for ECS exec
+// and returns the task and container to exec into.
+func execPreflight(ctx context.Context, cfg aws.Config, clusterName, serviceName, containerName string) (*types.Task, *types.Container, error) {
+	service, err := describeServiceByName(ctx, cfg, clusterName, serviceName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if service == nil {
+		return nil, nil, fmt.Errorf("service %s not found in cluster %s", serviceName, clusterName)
+	}
+
+	if !service.EnableExecuteCommand {
+		return nil, nil, fmt.Errorf(
+			"ECS exec is not enabled for %s. Enable it with `aws ecs update-service --cluster %s --service %s --enable-execute-command --force-new-deployment`",
+			serviceName, clusterName, serviceName,
+		)
+	}
+
+	taskDefinition, err := DescribeTaskDefinition(ctx, cfg, aws.ToString(service.TaskDefinition))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if taskDefinition.TaskRoleArn == nil {
+		return nil, nil, fmt.Errorf("%s has no task role. ECS exec needs a task role allowing %s", aws.ToString(service.TaskDefinition), strings.Join(execTaskRoleActions, ", "))
+	}
+
+	denials, err := iam.SimulatePrincipalPolicy(ctx, cfg, aws.ToString(taskDefinition.TaskRoleArn), execTaskRoleActions, nil, nil)
+	if err != nil {
+		logger.Warn("Unable to verify task role permissions. Error: %s", err.Error())
+	} else {
+		denied := make([]string, 0)
+		for _, denial := range denials {
+			if denial.Certain() {
+				denied = append(denied, denial.String())
+			} else {
+				logger.Warn("Task role %s may not be allowed %s", aws.ToString(taskDefinition.TaskRoleArn), denial.String())
+			}
+		}
+
+		if len(denied) > 0 {
+			return nil, nil, fmt.Errorf("task role %s is missing permissions required by ECS exec: %s", aws.ToString(taskDefinition.TaskRoleArn), strings.Join(denied, ", "))
+		}
+	}
+
+	ecsHandler := ecsLib.NewFromConfig(cfg)
+	tasksOutput, err := ecsHandler.ListTasks(ctx, &ecsLib.ListTasksInput{
+		Cluster:       aws.String(clusterName),
+		ServiceName:   aws.String(serviceName),
+		DesiredStatus: types.DesiredStatusRunning,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(tasksOutput.TaskArns) == 0 {
+		return nil, nil, errors.New("no running tasks for " + serviceName)
+	}
+
+	detailedTasks, err := ecsHandler.DescribeTasks(ctx, &ecsLib.DescribeTasksInput{
+		Cluster: aws.String(clusterName),
+		Tasks:   tasksOutput.TaskArns,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, task := range detailedTasks.Tasks {
+		if aws.ToString(task.LastStatus) != "RUNNING" {
+			continue
+		}
+
+		for j, container := range task.Containers {
+			if containerName != "" && aws.ToString(container.Name) != containerName {
+				continue
+			}
+
+			for _, agent := range container.ManagedAgents {
+				if agent.Name == types.ManagedAgentNameExecuteCommandAgent && aws.ToString(agent.LastStatus) == "RUNNING" {
+					return &detailedTasks.Tasks[i], &detailedTasks.Tasks[i].Containers[j], nil
+				}
+			}
+		}
+	}
+
+	if containerName != "" {
+		return nil, nil, fmt.Errorf("no running task of %s has container %s with a running exec agent. Tasks started before exec was enabled need a restart", serviceName, containerName)
+	}
+
+	return nil, nil, fmt.Errorf("no running task of %s has a running exec agent. Tasks started before exec was enabled need a restart", serviceName)
+}
+
+func extractTaskID(taskArn string) string {
+	a := strings.Split(taskArn, "/")
+	return a[len(a)-1]
+}
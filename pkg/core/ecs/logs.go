@@ -0,0 +1,141 @@
+package ecs
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"bitbucket.org/agrim123/onyx/pkg/core/logs"
+	"bitbucket.org/agrim123/onyx/pkg/logger"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecsLib "github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+// ServiceLogs prints the awslogs events of the running tasks of a service, interleaved and prefixed by task id
+func ServiceLogs(ctx context.Context, cfg aws.Config, clusterName, serviceName, containerName string, since time.Duration, follow bool, grep string) error {
+	var grepRegexp *regexp.Regexp
+	if grep != "" {
+		var err error
+		grepRegexp, err = regexp.Compile(grep)
+		if err != nil {
+			return errors.New("invalid grep pattern. Error: " + err.Error())
+		}
+	}
+
+	service, err := DescribeService(ctx, cfg, clusterName, serviceName)
+	if err != nil {
+		return err
+	}
+
+	taskDefinition, err := DescribeTaskDefinition(ctx, cfg, aws.ToString(service.TaskDefinition))
+	if err != nil {
+		return err
+	}
+
+	ecsHandler := ecsLib.NewFromConfig(cfg)
+
+	// Tasks are listed again on each poll while following, so replacements started since are included
+	serviceSources := func() ([]logs.Source, error) {
+		taskArns := make([]string, 0)
+
+		var nextToken *string
+		for {
+			output, err := ecsHandler.ListTasks(ctx, &ecsLib.ListTasksInput{
+				Cluster:       aws.String(clusterName),
+				ServiceName:   service.ServiceName,
+				DesiredStatus: types.DesiredStatusRunning,
+				NextToken:     nextToken,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			taskArns = append(taskArns, output.TaskArns...)
+
+			if output.NextToken == nil {
+				break
+			}
+
+			nextToken = output.NextToken
+		}
+
+		if len(taskArns) == 0 {
+			return nil, errors.New("no running tasks for " + serviceName)
+		}
+
+		return taskLogSources(taskDefinition, taskArns, containerName)
+	}
+
+	sources, err := serviceSources()
+	if err != nil {
+		return err
+	}
+
+	input := logs.TailInput{
+		Sources: sources,
+		Start:   time.Now().Add(-since),
+		Follow:  follow,
+		Grep:    grepRegexp,
+	}
+	if follow {
+		input.Refresh = serviceSources
+	}
+
+	return logs.Tail(ctx, cfg, input)
+}
+
+// taskLogSources resolves the awslogs streams of the containers of the given tasks, labelled by task id,
+// in the region each container ships its logs to.
+func taskLogSources(taskDefinition *types.TaskDefinition, taskArns []string, containerName string) ([]logs.Source, error) {
+	multipleContainers := len(taskDefinition.ContainerDefinitions) > 1 && containerName == ""
+
+	sources := make([]logs.Source, 0)
+	for _, container := range taskDefinition.ContainerDefinitions {
+		name := aws.ToString(container.Name)
+		if containerName != "" && name != containerName {
+			continue
+		}
+
+		if container.LogConfiguration == nil || container.LogConfiguration.LogDriver != types.LogDriverAwslogs {
+			logger.Warn("Container %s does not use the awslogs log driver, skipping", logger.Bold(name))
+			continue
+		}
+
+		options := container.LogConfiguration.Options
+		if options["awslogs-stream-prefix"] == "" {
+			logger.Warn("Container %s has no awslogs-stream-prefix, unable to resolve its log streams", logger.Bold(name))
+			continue
+		}
+
+		for _, taskArn := range taskArns {
+			taskID := extractTaskID(taskArn)
+
+			label := taskID
+			if len(label) > 8 {
+				label = label[:8]
+			}
+			if multipleContainers {
+				label += "/" + name
+			}
+
+			sources = append(sources, logs.Source{
+				Region: options["awslogs-region"],
+				Group:  options["awslogs-group"],
+				Stream: options["awslogs-stream-prefix"] + "/" + name + "/" + taskID,
+				Label:  label,
+			})
+		}
+	}
+
+	if len(sources) == 0 {
+		if containerName != "" {
+			return nil, errors.New("no awslogs configuration found for container " + containerName)
+		}
+
+		return nil, errors.New("no awslogs configuration found in " + aws.ToString(taskDefinition.TaskDefinitionArn))
+	}
+
+	return sources, nil
+}
@@ -0,0 +1,187 @@
+package ecs
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type MatchMode string
+
+const (
+	MatchExact  MatchMode = "exact"
+	MatchPrefix MatchMode = "prefix"
+	MatchFuzzy  MatchMode = "fuzzy"
+	MatchRegex  MatchMode = "regex"
+)
+
+// Number of suggestions shown when no service matches
+const maxSuggestions = 3
+
+// serviceMatchMode is the mode used by all ecs commands to match `--service` against service names
+var serviceMatchMode = MatchExact
+
+// SetMatchMode sets how service names are matched. Allowed values exact|prefix|fuzzy|regex
+func SetMatchMode(mode string) error {
+	switch MatchMode(strings.ToLower(mode)) {
+	case MatchExact, MatchPrefix, MatchFuzzy, MatchRegex:
+		serviceMatchMode = MatchMode(strings.ToLower(mode))
+		return nil
+	}
+
+	return errors.New("invalid match mode: " + mode + ". Allowed values: exact|prefix|fuzzy|regex")
+}
+
+type Matcher struct {
+	Mode    MatchMode
+	Pattern string
+	regexp  *regexp.Regexp
+}
+
+// NewMatcher returns a matcher for the pattern using the configured match mode
+func NewMatcher(pattern string) (*Matcher, error) {
+	matcher := &Matcher{
+		Mode:    serviceMatchMode,
+		Pattern: pattern,
+	}
+
+	if matcher.Mode == MatchRegex && pattern != "" {
+		r, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.New("invalid service pattern. Error: " + err.Error())
+		}
+		matcher.regexp = r
+	}
+
+	return matcher, nil
+}
+
+// Matches reports whether name matches the pattern. An empty pattern matches everything.
+func (m *Matcher) Matches(name string) bool {
+	if m.Pattern == "" {
+		return true
+	}
+
+	switch m.Mode {
+	case MatchPrefix:
+		return strings.HasPrefix(name, m.Pattern)
+	case MatchFuzzy:
+		return isSubsequence(strings.ToLower(m.Pattern), strings.ToLower(name))
+	case MatchRegex:
+		return m.regexp.MatchString(name)
+	}
+
+	return name == m.Pattern
+}
+
+// Filter returns the names matching the pattern
+func (m *Matcher) Filter(names []string) []string {
+	matched := make([]string, 0)
+	for _, name := range names {
+		if m.Matches(name) {
+			matched = append(matched, name)
+		}
+	}
+
+	return matched
+}
+
+// NotFoundError describes a pattern matching nothing, suggesting the closest names
+func (m *Matcher) NotFoundError(names []string, clusterName string) error {
+	message := fmt.Sprintf("no service matching %s (%s) in cluster %s", m.Pattern, m.Mode, clusterName)
+
+	suggestions := m.Suggest(names)
+	if len(suggestions) > 0 {
+		message += ". Did you mean: " + strings.Join(suggestions, ", ") + "?"
+	}
+
+	return errors.New(message)
+}
+
+// Suggest ranks names by closeness to the pattern, names containing it first, then by edit distance
+func (m *Matcher) Suggest(names []string) []string {
+	pattern := strings.ToLower(m.Pattern)
+
+	type candidate struct {
+		name     string
+		distance int
+	}
+
+	candidates := make([]candidate, 0)
+	for _, name := range names {
+		lower := strings.ToLower(name)
+
+		distance := levenshtein(pattern, lower)
+		if strings.Contains(lower, pattern) {
+			distance = 0
+		} else if distance > len(pattern)/2+2 {
+			continue
+		}
+
+		candidates = append(candidates, candidate{name: name, distance: distance})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+
+		return candidates[i].name < candidates[j].name
+	})
+
+	suggestions := make([]string, 0)
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		suggestions = append(suggestions, candidates[i].name)
+	}
+
+	return suggestions
+}
+
+func isSubsequence(pattern, s string) bool {
+	i := 0
+	for j := 0; i < len(pattern) && j < len(s); j++ {
+		if pattern[i] == s[j] {
+			i++
+		}
+	}
+
+	return i == len(pattern)
+}
+
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous = current
+	}
+
+	return previous[len(b)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+
+	return m
+}
@@ -0,0 +1,161 @@
+package ecs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"bitbucket.org/agrim123/onyx/pkg/utils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	metricsLib "github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	metricsTypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// CloudWatch returns at most this many datapoints per request
+const maxDatapoints = 1440
+
+var serviceMetricNames = []string{"CPUUtilization", "MemoryUtilization"}
+
+// MetricDatapoint is the aggregate of a metric over one period
+type MetricDatapoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Average   float64   `json:"average"`
+	Minimum   float64   `json:"minimum"`
+	Maximum   float64   `json:"maximum"`
+}
+
+// ServiceMetrics holds the utilization datapoints of a service, oldest first
+type ServiceMetrics struct {
+	Cluster string                       `json:"cluster"`
+	Service string                       `json:"service"`
+	Period  string                       `json:"period"`
+	Start   time.Time                    `json:"start"`
+	End     time.Time                    `json:"end"`
+	Metrics map[string][]MetricDatapoint `json:"metrics"`
+}
+
+// GetServiceMetrics fetches CPU and memory utilization of the service from CloudWatch
+func GetServiceMetrics(ctx context.Context, cfg aws.Config, clusterName, serviceName string, period, since time.Duration) (*ServiceMetrics, error) {
+	if period < time.Minute || period%time.Minute != 0 {
+		return nil, errors.New("period must be a multiple of 1m")
+	}
+
+	if since < period {
+		return nil, errors.New("since must be longer than period")
+	}
+
+	if since/period > maxDatapoints {
+		return nil, fmt.Errorf("since %s with period %s exceeds %d datapoints. Use a longer period", since, period, maxDatapoints)
+	}
+
+	service, err := DescribeService(ctx, cfg, clusterName, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	end := time.Now()
+	metrics := &ServiceMetrics{
+		Cluster: clusterName,
+		Service: aws.ToString(service.ServiceName),
+		Period:  period.String(),
+		Start:   end.Add(-since),
+		End:     end,
+		Metrics: make(map[string][]MetricDatapoint),
+	}
+
+	metricsHandler := metricsLib.NewFromConfig(cfg)
+	for _, metricName := range serviceMetricNames {
+		output, err := metricsHandler.GetMetricStatistics(ctx, &metricsLib.GetMetricStatisticsInput{
+			Namespace:  aws.String("AWS/ECS"),
+			MetricName: aws.String(metricName),
+			Dimensions: []metricsTypes.Dimension{
+				{Name: aws.String("ClusterName"), Value: aws.String(clusterName)},
+				{Name: aws.String("ServiceName"), Value: service.ServiceName},
+			},
+			StartTime:  aws.Time(metrics.Start),
+			EndTime:    aws.Time(metrics.End),
+			Period:     aws.Int32(int32(period.Seconds())),
+			Statistics: []metricsTypes.Statistic{metricsTypes.StatisticAverage, metricsTypes.StatisticMinimum, metricsTypes.StatisticMaximum},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		datapoints := make([]MetricDatapoint, 0)
+		for _, datapoint := range output.Datapoints {
+			datapoints = append(datapoints, MetricDatapoint{
+				Timestamp: aws.ToTime(datapoint.Timestamp),
+				Average:   aws.ToFloat64(datapoint.Average),
+				Minimum:   aws.ToFloat64(datapoint.Minimum),
+				Maximum:   aws.ToFloat64(datapoint.Maximum),
+			})
+		}
+
+		// Datapoints are returned in no particular order
+		sort.Slice(datapoints, func(i, j int) bool {
+			return datapoints[i].Timestamp.Before(datapoints[j].Timestamp)
+		})
+
+		metrics.Metrics[metricName] = datapoints
+	}
+
+	return metrics, nil
+}
+
+// Print renders a sparkline of the averages along with min, avg and max of each metric
+func (m *ServiceMetrics) Print() {
+	fmt.Println("Cluster Name:", m.Cluster)
+	fmt.Println("Service Name:", m.Service)
+	fmt.Printf("From %s to %s, period %s\n", m.Start.Local().Format(timeFormat), m.End.Local().Format(timeFormat), m.Period)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "METRIC\tMIN\tAVG\tMAX\tLAST\tTREND")
+	for _, metricName := range serviceMetricNames {
+		datapoints := m.Metrics[metricName]
+		if len(datapoints) == 0 {
+			fmt.Fprintf(w, "%s\t-\t-\t-\t-\tno data\n", metricName)
+			continue
+		}
+
+		min, max, sum := datapoints[0].Minimum, datapoints[0].Maximum, 0.0
+		averages := make([]float64, 0)
+		for _, datapoint := range datapoints {
+			if datapoint.Minimum < min {
+				min = datapoint.Minimum
+			}
+			if datapoint.Maximum > max {
+				max = datapoint.Maximum
+			}
+			sum += datapoint.Average
+			averages = append(averages, datapoint.Average)
+		}
+
+		// Utilization is a percentage of the reservation but can go above 100
+		scale := 100.0
+		if max > scale {
+			scale = max
+		}
+
+		fmt.Fprintf(w, "%s\t%.1f%%\t%.1f%%\t%.1f%%\t%.1f%%\t%s\n",
+			metricName,
+			min,
+			sum/float64(len(datapoints)),
+			max,
+			datapoints[len(datapoints)-1].Average,
+			utils.Sparkline(averages, scale),
+		)
+	}
+	w.Flush()
+}
+
+// PrintJSON writes the datapoints as JSON for graphing elsewhere
+func (m *ServiceMetrics) PrintJSON() error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(m)
+}
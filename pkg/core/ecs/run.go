@@ -0,0 +1,211 @@
+package ecs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bitbucket.org/agrim123/onyx/pkg/core/logs"
+	"bitbucket.org/agrim123/onyx/pkg/logger"
+	"bitbucket.org/agrim123/onyx/pkg/utils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecsLib "github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+const (
+	runTaskPollInterval = 5 * time.Second
+	// Consecutive DescribeTasks failures after which waiting is given up
+	maxRunTaskPollErrors = 5
+)
+
+type RunTaskInput struct {
+	ClusterName   string
+	ServiceName   string
+	ContainerName string
+	Command       string
+	Environment   []string
+	Wait          bool
+	Logs          bool
+	// Timeout is how long to wait for the task to stop, no limit if 0
+	Timeout time.Duration
+}
+
+// RunTaskFromService launches a standalone task with the task definition, network configuration and launch type of
+// the service, overriding the command and environment of a container. When waiting, it returns the exit code of that container.
+func RunTaskFromService(ctx context.Context, cfg aws.Config, input RunTaskInput) (int32, error) {
+	service, err := DescribeService(ctx, cfg, input.ClusterName, input.ServiceName)
+	if err != nil {
+		return 1, err
+	}
+
+	taskDefinition, err := DescribeTaskDefinition(ctx, cfg, aws.ToString(service.TaskDefinition))
+	if err != nil {
+		return 1, err
+	}
+
+	containerName := input.ContainerName
+	if containerName == "" {
+		containerName = aws.ToString(taskDefinition.ContainerDefinitions[0].Name)
+		for _, container := range taskDefinition.ContainerDefinitions {
+			if aws.ToBool(container.Essential) {
+				containerName = aws.ToString(container.Name)
+				break
+			}
+		}
+	}
+
+	containerOverride := types.ContainerOverride{
+		Name: aws.String(containerName),
+	}
+
+	if input.Command != "" {
+		command, err := utils.SplitCommand(input.Command)
+		if err != nil {
+			return 1, err
+		}
+		containerOverride.Command = command
+	}
+
+	for _, env := range input.Environment {
+		pair := strings.SplitN(env, "=", 2)
+		if len(pair) != 2 || pair[0] == "" {
+			return 1, errors.New("invalid environment variable: " + env + ". Expected KEY=VALUE")
+		}
+
+		containerOverride.Environment = append(containerOverride.Environment, types.KeyValuePair{
+			Name:  aws.String(pair[0]),
+			Value: aws.String(pair[1]),
+		})
+	}
+
+	runTaskInput := &ecsLib.RunTaskInput{
+		Cluster:              aws.String(input.ClusterName),
+		TaskDefinition:       service.TaskDefinition,
+		Count:                aws.Int32(1),
+		NetworkConfiguration: service.NetworkConfiguration,
+		PlacementConstraints: service.PlacementConstraints,
+		PlacementStrategy:    service.PlacementStrategy,
+		PlatformVersion:      service.PlatformVersion,
+		StartedBy:            aws.String("onyx"),
+		Overrides: &types.TaskOverride{
+			ContainerOverrides: []types.ContainerOverride{containerOverride},
+		},
+	}
+
+	// Launch type and capacity provider strategy are mutually exclusive
+	if len(service.CapacityProviderStrategy) > 0 {
+		runTaskInput.CapacityProviderStrategy = service.CapacityProviderStrategy
+	} else {
+		runTaskInput.LaunchType = service.LaunchType
+	}
+
+	ecsHandler := ecsLib.NewFromConfig(cfg)
+	output, err := ecsHandler.RunTask(ctx, runTaskInput)
+	if err != nil {
+		return 1, err
+	}
+
+	if len(output.Failures) > 0 {
+		return 1, fmt.Errorf("unable to run task. Reason: %s", aws.ToString(output.Failures[0].Reason))
+	}
+
+	if len(output.Tasks) == 0 {
+		return 1, errors.New("unable to run task. No task was started")
+	}
+
+	task := output.Tasks[0]
+	taskID := extractTaskID(aws.ToString(task.TaskArn))
+	logger.Success("Started task %s (%s) in %s", logger.Bold(taskID), aws.ToString(service.TaskDefinition), input.ClusterName)
+
+	if !input.Wait && !input.Logs {
+		return 0, nil
+	}
+
+	var waitErr error
+	pollErrors := 0
+	started := time.Now()
+	stopped := func() bool {
+		if input.Timeout > 0 && time.Since(started) > input.Timeout {
+			waitErr = fmt.Errorf("timed out after %s waiting for task %s to stop", input.Timeout, taskID)
+			return true
+		}
+
+		output, err := ecsHandler.DescribeTasks(ctx, &ecsLib.DescribeTasksInput{
+			Cluster: aws.String(input.ClusterName),
+			Tasks:   []string{aws.ToString(task.TaskArn)},
+		})
+		if err != nil {
+			pollErrors++
+			if pollErrors >= maxRunTaskPollErrors {
+				waitErr = errors.New("unable to describe task " + taskID + ". Error: " + err.Error())
+				return true
+			}
+
+			return false
+		}
+		pollErrors = 0
+
+		if len(output.Tasks) == 0 {
+			return false
+		}
+
+		task = output.Tasks[0]
+		return aws.ToString(task.LastStatus) == "STOPPED"
+	}
+
+	streamed := false
+	if input.Logs {
+		sources, err := taskLogSources(taskDefinition, []string{aws.ToString(task.TaskArn)}, containerName)
+		if err != nil {
+			logger.Warn("Unable to stream logs. Error: %s", err.Error())
+		} else {
+			err = logs.Tail(ctx, cfg, logs.TailInput{
+				Sources: sources,
+				Start:   aws.ToTime(task.CreatedAt),
+				Follow:  true,
+				Until:   stopped,
+			})
+			if err != nil {
+				logger.Warn("Unable to stream logs. Error: %s", err.Error())
+			} else {
+				streamed = true
+			}
+		}
+	}
+
+	if !streamed {
+		logger.Info("Waiting for task %s to stop", logger.Bold(taskID))
+		for !stopped() {
+			time.Sleep(runTaskPollInterval)
+		}
+	}
+
+	if waitErr != nil {
+		return 1, waitErr
+	}
+
+	logger.Info("Task %s stopped. Reason: %s", logger.Bold(taskID), aws.ToString(task.StoppedReason))
+
+	for _, container := range task.Containers {
+		if aws.ToString(container.Name) != containerName {
+			continue
+		}
+
+		if container.ExitCode == nil {
+			return 1, fmt.Errorf("container %s did not exit normally. Reason: %s", containerName, aws.ToString(container.Reason))
+		}
+
+		if *container.ExitCode == 0 {
+			logger.Success("Container %s exited with code 0", logger.Bold(containerName))
+		} else {
+			logger.Error("Container %s exited with code %d", logger.Bold(containerName), *container.ExitCode)
+		}
+
+		return *container.ExitCode, nil
+	}
+
+	return 1, errors.New("container " + containerName + " not found in task " + taskID)
+}
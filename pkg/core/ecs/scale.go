@@ -0,0 +1,149 @@
+package ecs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"bitbucket.org/agrim123/onyx/pkg/logger"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	autoscalingTypes "github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	ecsLib "github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+// Capacity is the desired count of a service along with its autoscaling bounds, if any
+type Capacity struct {
+	Desired     int32
+	Min         int32
+	Max         int32
+	Autoscaling bool
+}
+
+func (c Capacity) String() string {
+	if !c.Autoscaling {
+		return fmt.Sprintf("desired: %d (no autoscaling)", c.Desired)
+	}
+
+	return fmt.Sprintf("desired: %d | min: %d | max: %d", c.Desired, c.Min, c.Max)
+}
+
+// ScaleService updates the desired count of the service. When the service has an application autoscaling target,
+// its bounds are widened to include desired (or set to min/max when given, -1 otherwise) so the scaler does not undo it.
+func ScaleService(ctx context.Context, cfg aws.Config, clusterName, serviceName string, desired, min, max int32) error {
+	service, err := DescribeService(ctx, cfg, clusterName, serviceName)
+	if err != nil {
+		return err
+	}
+
+	if service.SchedulingStrategy == types.SchedulingStrategyDaemon {
+		return errors.New(serviceName + " uses DAEMON scheduling strategy and cannot be scaled")
+	}
+
+	if desired < 0 {
+		return errors.New("desired count cannot be negative")
+	}
+
+	a := strings.Split(aws.ToString(service.ClusterArn), "/")
+	resourceID := "service/" + a[len(a)-1] + "/" + aws.ToString(service.ServiceName)
+
+	autoscalingHandler := applicationautoscaling.NewFromConfig(cfg)
+	targetsOutput, err := autoscalingHandler.DescribeScalableTargets(ctx, &applicationautoscaling.DescribeScalableTargetsInput{
+		ServiceNamespace:  autoscalingTypes.ServiceNamespaceEcs,
+		ScalableDimension: autoscalingTypes.ScalableDimensionECSServiceDesiredCount,
+		ResourceIds:       []string{resourceID},
+	})
+	if err != nil {
+		return err
+	}
+
+	before := Capacity{
+		Desired: service.DesiredCount,
+	}
+	if len(targetsOutput.ScalableTargets) > 0 {
+		before.Autoscaling = true
+		before.Min = aws.ToInt32(targetsOutput.ScalableTargets[0].MinCapacity)
+		before.Max = aws.ToInt32(targetsOutput.ScalableTargets[0].MaxCapacity)
+	}
+
+	after := before
+	after.Desired = desired
+
+	if before.Autoscaling {
+		if min >= 0 {
+			after.Min = min
+		} else if desired < after.Min {
+			after.Min = desired
+		}
+
+		if max >= 0 {
+			after.Max = max
+		} else if desired > after.Max {
+			after.Max = desired
+		}
+
+		if after.Min > after.Desired || after.Desired > after.Max {
+			return fmt.Errorf("desired count %d is outside of min %d and max %d", after.Desired, after.Min, after.Max)
+		}
+	} else if min >= 0 || max >= 0 {
+		logger.Warn("%s has no autoscaling target, ignoring min and max", logger.Bold(serviceName))
+	}
+
+	ecsHandler := ecsLib.NewFromConfig(cfg)
+	updateDesired := func(count int32) error {
+		_, err := ecsHandler.UpdateService(ctx, &ecsLib.UpdateServiceInput{
+			Cluster:      aws.String(clusterName),
+			Service:      service.ServiceName,
+			DesiredCount: aws.Int32(count),
+		})
+		return err
+	}
+
+	registerBounds := func(capacity Capacity) error {
+		_, err := autoscalingHandler.RegisterScalableTarget(ctx, &applicationautoscaling.RegisterScalableTargetInput{
+			ResourceId:        aws.String(resourceID),
+			ServiceNamespace:  autoscalingTypes.ServiceNamespaceEcs,
+			ScalableDimension: autoscalingTypes.ScalableDimensionECSServiceDesiredCount,
+			MinCapacity:       aws.Int32(capacity.Min),
+			MaxCapacity:       aws.Int32(capacity.Max),
+		})
+		return err
+	}
+
+	if !before.Autoscaling || (after.Min == before.Min && after.Max == before.Max) {
+		if err := updateDesired(desired); err != nil {
+			return err
+		}
+	} else if after.Min < before.Min {
+		// Lowering min first would let the scaler scale in on its own before the new desired count is set
+		if err := updateDesired(desired); err != nil {
+			return err
+		}
+
+		if err := registerBounds(after); err != nil {
+			if rollbackErr := updateDesired(before.Desired); rollbackErr != nil {
+				return fmt.Errorf("unable to update autoscaling target. Error: %s. Unable to restore desired count %d. Error: %s", err.Error(), before.Desired, rollbackErr.Error())
+			}
+			return fmt.Errorf("unable to update autoscaling target, desired count restored to %d. Error: %s", before.Desired, err.Error())
+		}
+	} else {
+		if err := registerBounds(after); err != nil {
+			return errors.New("unable to update autoscaling target. Error: " + err.Error())
+		}
+
+		if err := updateDesired(desired); err != nil {
+			if rollbackErr := registerBounds(before); rollbackErr != nil {
+				return fmt.Errorf("unable to update desired count. Error: %s. Unable to restore autoscaling min %d and max %d. Error: %s", err.Error(), before.Min, before.Max, rollbackErr.Error())
+			}
+			return fmt.Errorf("unable to update desired count, autoscaling min %d and max %d restored. Error: %s", before.Min, before.Max, err.Error())
+		}
+	}
+
+	logger.Success("Scaled %s", logger.Bold(aws.ToString(service.ServiceName)))
+	fmt.Println("  Before:", before)
+	fmt.Println("  After: ", after)
+
+	return nil
+}
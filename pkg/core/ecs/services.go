@@ -1,8 +1,166 @@
 package ecs
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bitbucket.org/agrim123/onyx/pkg/logger"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecsLib "github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+const (
+	steadyStatePollInterval = 10 * time.Second
+	steadyStateTimeout      = 15 * time.Minute
+)
+
 type Service struct {
-	Arn               *string
-	Name              string
-	TaskDefinitionArn string
-	Tasks             []Task
+	Arn                *string
+	Name               string
+	TaskDefinitionArn  string
+	Tasks              []Task
+	DesiredCount       int32
+	RunningCount       int32
+	PendingCount       int32
+	DeploymentState    string
+	SchedulingStrategy string
+}
+
+// ParseSchedulingStrategy converts user input to a scheduling strategy, empty meaning all strategies
+func ParseSchedulingStrategy(strategy string) (types.SchedulingStrategy, error) {
+	switch strings.ToUpper(strategy) {
+	case "":
+		return "", nil
+	case string(types.SchedulingStrategyReplica):
+		return types.SchedulingStrategyReplica, nil
+	case string(types.SchedulingStrategyDaemon):
+		return types.SchedulingStrategyDaemon, nil
+	}
+
+	return "", errors.New("invalid strategy: " + strategy + ". Allowed values: replica|daemon")
+}
+
+// deploymentState summarizes the deployments of a service, preferring the rollout state of the primary deployment
+func deploymentState(deployments []types.Deployment) string {
+	if len(deployments) > 1 {
+		return fmt.Sprintf("IN_PROGRESS (%d deployments)", len(deployments))
+	}
+
+	for _, deployment := range deployments {
+		if deployment.RolloutState != "" {
+			return string(deployment.RolloutState)
+		}
+	}
+
+	return "COMPLETED"
+}
+
+// DescribeService resolves serviceName to a single service of the cluster using the configured match mode,
+// asking the user to choose when several services match.
+func DescribeService(ctx context.Context, cfg aws.Config, clusterName, serviceName string) (*types.Service, error) {
+	if serviceName == "" {
+		return nil, errors.New("empty service name")
+	}
+
+	if serviceMatchMode == MatchExact {
+		service, err := describeServiceByName(ctx, cfg, clusterName, serviceName)
+		if err != nil || service != nil {
+			return service, err
+		}
+	}
+
+	// Lists services matching the name, failing with suggestions if none does
+	cluster := Cluster{
+		Name: clusterName,
+	}
+
+	if err := cluster.GetServices(ctx, cfg, serviceName); err != nil {
+		return nil, err
+	}
+
+	services := cluster.Services
+	if len(services) > 1 {
+		selectedServices, err := cluster.SelectServices("Multiple services match " + serviceName + ". Select one:")
+		if err != nil {
+			return nil, err
+		}
+
+		if len(selectedServices) != 1 {
+			return nil, errors.New("select exactly one service")
+		}
+
+		services = selectedServices
+	}
+
+	service, err := describeServiceByName(ctx, cfg, clusterName, services[0].Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if service == nil {
+		return nil, fmt.Errorf("service %s not found in cluster %s", services[0].Name, clusterName)
+	}
+
+	return service, nil
+}
+
+// describeServiceByName returns the service with exactly the given name, nil if the cluster has no such active service
+func describeServiceByName(ctx context.Context, cfg aws.Config, clusterName, serviceName string) (*types.Service, error) {
+	ecsHandler := ecsLib.NewFromConfig(cfg)
+	output, err := ecsHandler.DescribeServices(ctx, &ecsLib.DescribeServicesInput{
+		Cluster:  aws.String(clusterName),
+		Services: []string{serviceName},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(output.Services) == 0 || aws.ToString(output.Services[0].Status) == "INACTIVE" {
+		return nil, nil
+	}
+
+	return &output.Services[0], nil
+}
+
+// WaitForSteadyState polls the service until only the primary deployment is left and all its tasks are running
+func WaitForSteadyState(ctx context.Context, cfg aws.Config, clusterName, serviceName string) error {
+	logger.Info("Waiting for %s to reach steady state", logger.Bold(serviceName))
+
+	deadline := time.Now().Add(steadyStateTimeout)
+	for time.Now().Before(deadline) {
+		service, err := describeServiceByName(ctx, cfg, clusterName, serviceName)
+		if err != nil {
+			return err
+		}
+
+		if service == nil {
+			return fmt.Errorf("service %s not found in cluster %s", serviceName, clusterName)
+		}
+
+		for _, deployment := range service.Deployments {
+			if aws.ToString(deployment.Status) != "PRIMARY" {
+				continue
+			}
+
+			if deployment.RolloutState == types.DeploymentRolloutStateFailed {
+				return errors.New("deployment failed: " + aws.ToString(deployment.RolloutStateReason))
+			}
+
+			fmt.Printf("  deployments: %d | desired: %d | running: %d | pending: %d | failed: %d\n",
+				len(service.Deployments), deployment.DesiredCount, deployment.RunningCount, deployment.PendingCount, deployment.FailedTasks)
+
+			if len(service.Deployments) == 1 && deployment.RunningCount == deployment.DesiredCount {
+				logger.Success("%s reached steady state", logger.Bold(serviceName))
+				return nil
+			}
+		}
+
+		time.Sleep(steadyStatePollInterval)
+	}
+
+	return fmt.Errorf("timed out after %s waiting for %s to reach steady state", steadyStateTimeout, serviceName)
 }
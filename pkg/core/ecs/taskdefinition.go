@@ -0,0 +1,261 @@
+package ecs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bitbucket.org/agrim123/onyx/pkg/core/iam"
+	"bitbucket.org/agrim123/onyx/pkg/logger"
+	"bitbucket.org/agrim123/onyx/pkg/utils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecsLib "github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+// TaskDefinitionRevision is the summary of a single revision of a task definition family
+type TaskDefinitionRevision struct {
+	Arn          string
+	Family       string
+	Revision     int32
+	Status       types.TaskDefinitionStatus
+	Images       []string
+	RegisteredAt *time.Time
+}
+
+func (r *TaskDefinitionRevision) Print(current bool) {
+	line := fmt.Sprintf("%s:%d (%s) registered at %s", r.Family, r.Revision, r.Status, formatTime(r.RegisteredAt))
+	if current {
+		line += logger.Bold("    <------- current")
+	}
+
+	fmt.Println(line)
+	for _, image := range r.Images {
+		fmt.Println("    Image:", image)
+	}
+}
+
+// parseTaskDefinitionArn extracts family and revision from a task definition arn or `family:revision` string
+func parseTaskDefinitionArn(arn string) (string, int32) {
+	a := strings.Split(arn, "/")
+	familyRevision := a[len(a)-1]
+
+	i := strings.LastIndex(familyRevision, ":")
+	if i < 0 {
+		return familyRevision, 0
+	}
+
+	revision, err := strconv.ParseInt(familyRevision[i+1:], 10, 32)
+	if err != nil {
+		return familyRevision, 0
+	}
+
+	return familyRevision[:i], int32(revision)
+}
+
+// DescribeTaskDefinition returns the task definition for the given arn or `family[:revision]`
+func DescribeTaskDefinition(ctx context.Context, cfg aws.Config, taskDefinition string) (*types.TaskDefinition, error) {
+	ecsHandler := ecsLib.NewFromConfig(cfg)
+	output, err := ecsHandler.DescribeTaskDefinition(ctx, &ecsLib.DescribeTaskDefinitionInput{
+		TaskDefinition: aws.String(taskDefinition),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return output.TaskDefinition, nil
+}
+
+// ListTaskDefinitionRevisions returns the most recent revisions, both ACTIVE and INACTIVE, of a task definition family
+func ListTaskDefinitionRevisions(ctx context.Context, cfg aws.Config, family string, limit int) ([]TaskDefinitionRevision, error) {
+	ecsHandler := ecsLib.NewFromConfig(cfg)
+
+	arns := make([]string, 0)
+	for _, status := range []types.TaskDefinitionStatus{types.TaskDefinitionStatusActive, types.TaskDefinitionStatusInactive} {
+		found := 0
+
+		var nextToken *string
+		for found < limit {
+			output, err := ecsHandler.ListTaskDefinitions(ctx, &ecsLib.ListTaskDefinitionsInput{
+				FamilyPrefix: aws.String(family),
+				Sort:         types.SortOrderDesc,
+				Status:       status,
+				NextToken:    nextToken,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			// Family prefix also matches other families sharing the prefix
+			for _, arn := range output.TaskDefinitionArns {
+				if f, _ := parseTaskDefinitionArn(arn); f == family && found < limit {
+					arns = append(arns, arn)
+					found++
+				}
+			}
+
+			if output.NextToken == nil {
+				break
+			}
+
+			nextToken = output.NextToken
+		}
+	}
+
+	sort.Slice(arns, func(i, j int) bool {
+		_, a := parseTaskDefinitionArn(arns[i])
+		_, b := parseTaskDefinitionArn(arns[j])
+		return a > b
+	})
+
+	if len(arns) > limit {
+		arns = arns[:limit]
+	}
+
+	revisions := make([]TaskDefinitionRevision, 0)
+	for _, arn := range arns {
+		taskDefinition, err := DescribeTaskDefinition(ctx, cfg, arn)
+		if err != nil {
+			return nil, err
+		}
+
+		images := make([]string, 0)
+		for _, container := range taskDefinition.ContainerDefinitions {
+			images = append(images, fmt.Sprintf("%s (%s)", aws.ToString(container.Image), aws.ToString(container.Name)))
+		}
+
+		revisions = append(revisions, TaskDefinitionRevision{
+			Arn:          aws.ToString(taskDefinition.TaskDefinitionArn),
+			Family:       aws.ToString(taskDefinition.Family),
+			Revision:     taskDefinition.Revision,
+			Status:       taskDefinition.Status,
+			Images:       images,
+			RegisteredAt: taskDefinition.RegisteredAt,
+		})
+	}
+
+	return revisions, nil
+}
+
+// previousTaskDefinitionRevision returns the highest ACTIVE or INACTIVE revision of the family lower than current,
+// 0 if none. Only arns are listed, however far back the revision is.
+func previousTaskDefinitionRevision(ctx context.Context, cfg aws.Config, family string, current int32) (int32, error) {
+	ecsHandler := ecsLib.NewFromConfig(cfg)
+
+	var previous int32
+	for _, status := range []types.TaskDefinitionStatus{types.TaskDefinitionStatusActive, types.TaskDefinitionStatusInactive} {
+		var nextToken *string
+		for {
+			output, err := ecsHandler.ListTaskDefinitions(ctx, &ecsLib.ListTaskDefinitionsInput{
+				FamilyPrefix: aws.String(family),
+				Sort:         types.SortOrderDesc,
+				Status:       status,
+				NextToken:    nextToken,
+			})
+			if err != nil {
+				return 0, err
+			}
+
+			found := false
+			for _, arn := range output.TaskDefinitionArns {
+				// Family prefix also matches other families sharing the prefix
+				if f, revision := parseTaskDefinitionArn(arn); f == family && revision < current {
+					if revision > previous {
+						previous = revision
+					}
+					found = true
+					break
+				}
+			}
+
+			// Arns are sorted by revision, the first lower one is the highest
+			if found || output.NextToken == nil {
+				break
+			}
+
+			nextToken = output.NextToken
+		}
+	}
+
+	return previous, nil
+}
+
+// RollbackService updates the service to an older revision of its task definition family and waits for steady state.
+// If toRevision is 0, the user is asked to pick one, defaulting to the previous revision.
+func RollbackService(ctx context.Context, cfg aws.Config, clusterName, serviceName string, toRevision int32, force bool) error {
+	service, err := DescribeService(ctx, cfg, clusterName, serviceName)
+	if err != nil {
+		return err
+	}
+
+	family, currentRevision := parseTaskDefinitionArn(aws.ToString(service.TaskDefinition))
+
+	revisions, err := ListTaskDefinitionRevisions(ctx, cfg, family, 10)
+	if err != nil {
+		return err
+	}
+
+	previousRevision, err := previousTaskDefinitionRevision(ctx, cfg, family, currentRevision)
+	if err != nil {
+		return err
+	}
+
+	if toRevision == 0 {
+		fmt.Println("Cluster Name:", clusterName)
+		fmt.Println("Service Name:", aws.ToString(service.ServiceName))
+		fmt.Println("Recent revisions:")
+		for _, revision := range revisions {
+			revision.Print(revision.Revision == currentRevision)
+		}
+
+		if previousRevision == 0 {
+			return errors.New("no previous revision found for " + family)
+		}
+
+		choice := strings.TrimSpace(utils.GetUserInput(fmt.Sprintf("Enter revision to roll back to [%d]: ", previousRevision)))
+		if choice == "" {
+			toRevision = previousRevision
+		} else {
+			r, err := strconv.ParseInt(choice, 10, 32)
+			if err != nil {
+				return errors.New("invalid revision: " + choice)
+			}
+			toRevision = int32(r)
+		}
+	}
+
+	if toRevision == currentRevision {
+		return fmt.Errorf("service is already running %s:%d", family, currentRevision)
+	}
+
+	taskDefinition, err := DescribeTaskDefinition(ctx, cfg, fmt.Sprintf("%s:%d", family, toRevision))
+	if err != nil {
+		return err
+	}
+
+	if taskDefinition.Status == types.TaskDefinitionStatusInactive && !force {
+		return fmt.Errorf("%s:%d is INACTIVE. Use `--force` to roll back to it anyway", family, toRevision)
+	}
+
+	if err := iam.Preflight(ctx, cfg, []string{"ecs:UpdateService"}, []string{aws.ToString(service.ServiceArn)}); err != nil {
+		return err
+	}
+
+	ecsHandler := ecsLib.NewFromConfig(cfg)
+	_, err = ecsHandler.UpdateService(ctx, &ecsLib.UpdateServiceInput{
+		Cluster:        aws.String(clusterName),
+		Service:        service.ServiceName,
+		TaskDefinition: taskDefinition.TaskDefinitionArn,
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Success("Rolling back %s from %s:%d to %s:%d", logger.Bold(aws.ToString(service.ServiceName)), family, currentRevision, family, toRevision)
+
+	return WaitForSteadyState(ctx, cfg, clusterName, aws.ToString(service.ServiceName))
+}
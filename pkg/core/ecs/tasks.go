@@ -2,7 +2,9 @@ package ecs
 
 import (
 	"context"
+	"strings"
 
+	"bitbucket.org/agrim123/onyx/pkg/utils"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	ecsLib "github.com/aws/aws-sdk-go-v2/service/ecs"
 )
@@ -17,36 +19,58 @@ type Task struct {
 func DescribeTasks(ctx context.Context, cfg aws.Config, clusterName string, services *[]Service) *map[string]Task {
 	ecsHandler := ecsLib.NewFromConfig(cfg)
 
+	servicesMap := make(map[string]*Service)
 	tasksArns := make([]string, 0)
-	for _, service := range *services {
-		allTasksOutput, err := ecsHandler.ListTasks(ctx, &ecsLib.ListTasksInput{
-			Cluster:     &clusterName,
-			ServiceName: aws.String(service.Name),
-		})
-		if err == nil {
+	for i, service := range *services {
+		servicesMap[service.Name] = &(*services)[i]
+
+		var nextToken *string
+		for {
+			allTasksOutput, err := ecsHandler.ListTasks(ctx, &ecsLib.ListTasksInput{
+				Cluster:     &clusterName,
+				ServiceName: aws.String(service.Name),
+				NextToken:   nextToken,
+			})
+			if err != nil {
+				break
+			}
+
 			tasksArns = append(tasksArns, allTasksOutput.TaskArns...)
+
+			if allTasksOutput.NextToken == nil {
+				break
+			}
+
+			nextToken = allTasksOutput.NextToken
 		}
 	}
 
-	detailedTasks, err := ecsHandler.DescribeTasks(ctx, &ecsLib.DescribeTasksInput{
-		Cluster: &clusterName,
-		Tasks:   tasksArns,
-	})
-
 	allTasks := make(map[string]Task)
-	if err != nil {
-		return &allTasks
-	}
+	for _, chunk := range utils.GetChunks(tasksArns, 100) {
+		detailedTasks, err := ecsHandler.DescribeTasks(ctx, &ecsLib.DescribeTasksInput{
+			Cluster: &clusterName,
+			Tasks:   chunk,
+		})
+		if err != nil {
+			continue
+		}
+
+		for _, task := range detailedTasks.Tasks {
+			if task.ContainerInstanceArn != nil {
+				// Tasks started by a service are grouped as `service:<service-name>`
+				service := servicesMap[strings.TrimPrefix(aws.ToString(task.Group), "service:")]
+				if service == nil {
+					service = &Service{}
+				}
 
-	for _, task := range detailedTasks.Tasks {
-		if task.ContainerInstanceArn != nil {
-			allTasks[*task.TaskArn] = Task{
-				Arn:               task.TaskArn,
-				TaskDefinitionArn: *task.TaskDefinitionArn,
-				ContainerInstance: &ContainerInstance{
-					Arn: task.ContainerInstanceArn,
-				},
-				Service: &Service{},
+				allTasks[*task.TaskArn] = Task{
+					Arn:               task.TaskArn,
+					TaskDefinitionArn: *task.TaskDefinitionArn,
+					ContainerInstance: &ContainerInstance{
+						Arn: task.ContainerInstanceArn,
+					},
+					Service: service,
+				}
 			}
 		}
 	}
@@ -2,12 +2,105 @@ package iam
 
 import (
 	"context"
+	"errors"
 	"log"
+	"strings"
 
+	onyxConfig "bitbucket.org/agrim123/onyx/pkg/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 )
 
+const (
+	PrincipalUser          = "user"
+	PrincipalRoot          = "root"
+	PrincipalAssumedRole   = "assumed-role"
+	PrincipalFederatedUser = "federated-user"
+)
+
+// Identity is the principal the configured credentials belong to
+type Identity struct {
+	Account       string
+	Arn           string
+	UserID        string
+	PrincipalType string
+	// Name is the user name for users and the role name for assumed roles
+	Name string
+	// SessionName is the role session name of assumed roles, e.g. the email for SSO sessions
+	SessionName string
+}
+
+// User is the name the principal is known by: the user name for users and the session name of
+// assumed roles, mapped to a canonical user name if configured in the onyx config
+func (i *Identity) User() string {
+	switch i.PrincipalType {
+	case PrincipalUser, PrincipalFederatedUser:
+		return i.Name
+	case PrincipalRoot:
+		return "root"
+	}
+
+	if c, err := onyxConfig.Load(); err == nil {
+		if user, ok := c.Users[i.SessionName]; ok {
+			return user
+		}
+	}
+
+	return i.SessionName
+}
+
+// parseArn fills principal type, name and session name from the caller arn, e.g.
+// arn:aws:iam::123456789012:user/path/name or arn:aws:sts::123456789012:assumed-role/role-name/session-name
+func (i *Identity) parseArn() error {
+	a := strings.SplitN(i.Arn, ":", 6)
+	if len(a) != 6 {
+		return errors.New("invalid caller arn " + i.Arn)
+	}
+
+	resource := strings.Split(a[5], "/")
+	i.PrincipalType = resource[0]
+
+	switch i.PrincipalType {
+	case PrincipalRoot:
+	case PrincipalUser, PrincipalFederatedUser:
+		i.Name = resource[len(resource)-1]
+	case PrincipalAssumedRole:
+		if len(resource) < 3 {
+			return errors.New("invalid assumed role arn " + i.Arn)
+		}
+		i.Name = resource[1]
+		i.SessionName = strings.Join(resource[2:], "/")
+	default:
+		return errors.New("unknown principal type in arn " + i.Arn)
+	}
+
+	return nil
+}
+
+// GetCallerIdentity returns the identity of the credentials in cfg. Unlike iam:GetUser,
+// it works for users, assumed roles, SSO sessions and instance profiles.
+func GetCallerIdentity(ctx context.Context, cfg aws.Config) (*Identity, error) {
+	stsHandler := sts.NewFromConfig(cfg)
+	output, err := stsHandler.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{
+		Account: aws.ToString(output.Account),
+		Arn:     aws.ToString(output.Arn),
+		UserID:  aws.ToString(output.UserId),
+	}
+
+	if err := identity.parseArn(); err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+// Whoami returns the name of the user making requests, see Identity.User
 func Whoami() (string, error) {
 	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("us-east-1"))
 	if err != nil {
@@ -15,11 +108,10 @@ func Whoami() (string, error) {
 	}
 	ctx := context.Background()
 
-	iamHandler := iam.NewFromConfig(cfg)
-	output, err := iamHandler.GetUser(ctx, &iam.GetUserInput{})
+	identity, err := GetCallerIdentity(ctx, cfg)
 	if err != nil {
 		return "", err
 	}
 
-	return *output.User.UserName, nil
+	return identity.User(), nil
 }
@@ -0,0 +1,339 @@
+package iam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"bitbucket.org/agrim123/onyx/pkg/logger"
+	"bitbucket.org/agrim123/onyx/pkg/utils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+const (
+	// Keys older than this are due for rotation as per the security policy
+	maxAccessKeyAge = 90 * 24 * time.Hour
+	// New keys take a few seconds to be usable
+	keyVerificationTimeout  = time.Minute
+	keyVerificationInterval = 5 * time.Second
+
+	sharedCredentialsSource = "SharedConfigCredentials: "
+)
+
+// callerUserName returns the user name of the caller, erroring for principals that have no access keys
+func callerUserName(ctx context.Context, cfg aws.Config) (string, error) {
+	identity, err := GetCallerIdentity(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	if identity.PrincipalType != PrincipalUser {
+		return "", errors.New("access keys belong to IAM users, the current credentials are of a " + identity.PrincipalType + " principal")
+	}
+
+	return identity.Name, nil
+}
+
+func listAccessKeys(ctx context.Context, cfg aws.Config, userName string) ([]types.AccessKeyMetadata, error) {
+	iamHandler := iam.NewFromConfig(cfg)
+
+	keys := make([]types.AccessKeyMetadata, 0)
+
+	var marker *string
+	for {
+		output, err := iamHandler.ListAccessKeys(ctx, &iam.ListAccessKeysInput{
+			UserName: aws.String(userName),
+			Marker:   marker,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, output.AccessKeyMetadata...)
+
+		if !output.IsTruncated {
+			break
+		}
+
+		marker = output.Marker
+	}
+
+	return keys, nil
+}
+
+// PrintAccessKeys lists the access keys of the caller with their age and when and where they were last used
+func PrintAccessKeys(ctx context.Context, cfg aws.Config) error {
+	userName, err := callerUserName(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	keys, err := listAccessKeys(ctx, cfg, userName)
+	if err != nil {
+		return err
+	}
+
+	current, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return err
+	}
+
+	iamHandler := iam.NewFromConfig(cfg)
+
+	fmt.Println("User Name:", userName)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACCESS KEY\tSTATUS\tAGE\tLAST USED\tSERVICE\tREGION\t")
+	due := 0
+	for _, key := range keys {
+		lastUsed, service, region := "never", "-", "-"
+
+		output, err := iamHandler.GetAccessKeyLastUsed(ctx, &iam.GetAccessKeyLastUsedInput{
+			AccessKeyId: key.AccessKeyId,
+		})
+		if err != nil {
+			logger.Warn("Unable to get last use of %s. Error: %s", aws.ToString(key.AccessKeyId), err.Error())
+		} else if output.AccessKeyLastUsed != nil && output.AccessKeyLastUsed.LastUsedDate != nil {
+			lastUsed = utils.HumanizeDuration(time.Since(*output.AccessKeyLastUsed.LastUsedDate)) + " ago"
+			service = aws.ToString(output.AccessKeyLastUsed.ServiceName)
+			region = aws.ToString(output.AccessKeyLastUsed.Region)
+		}
+
+		age := time.Since(aws.ToTime(key.CreateDate))
+
+		marker := ""
+		if aws.ToString(key.AccessKeyId) == current.AccessKeyID {
+			marker = "<------- current"
+		}
+		if age > maxAccessKeyAge && key.Status == types.StatusTypeActive {
+			marker = strings.TrimSpace(marker + " " + logger.Red("due for rotation"))
+			due++
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			aws.ToString(key.AccessKeyId),
+			key.Status,
+			utils.HumanizeDuration(age),
+			lastUsed,
+			service,
+			region,
+			marker,
+		)
+	}
+	w.Flush()
+
+	if due > 0 {
+		logger.Warn("%d key(s) older than %d days. Rotate with `onyx iam keys rotate`", due, int(maxAccessKeyAge.Hours()/24))
+	}
+
+	return nil
+}
+
+// RotateAccessKey replaces the access key of the profile in the shared credentials file with a new one.
+// The new key is verified with sts before the old one is deactivated and deleted; if verification fails,
+// the new key is deleted and the credentials file restored.
+func RotateAccessKey(ctx context.Context, cfg aws.Config, profile string) error {
+	userName, err := callerUserName(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	current, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(current.Source, sharedCredentialsSource) {
+		return errors.New("only keys from the shared credentials file can be rotated, current credentials come from " + current.Source)
+	}
+	credentialsFile := strings.TrimPrefix(current.Source, sharedCredentialsSource)
+
+	// Profiles other than default are named `profile <name>` in the shared config file
+	section := profile
+	if filepath.Base(credentialsFile) == "config" && profile != "default" {
+		section = "profile " + profile
+	}
+
+	keys, err := listAccessKeys(ctx, cfg, userName)
+	if err != nil {
+		return err
+	}
+
+	// IAM users can have at most two access keys
+	for _, key := range keys {
+		if aws.ToString(key.AccessKeyId) != current.AccessKeyID {
+			return fmt.Errorf("%s already has a second access key %s (%s). Delete it before rotating", userName, aws.ToString(key.AccessKeyId), key.Status)
+		}
+	}
+
+	iamHandler := iam.NewFromConfig(cfg)
+	output, err := iamHandler.CreateAccessKey(ctx, &iam.CreateAccessKeyInput{
+		UserName: aws.String(userName),
+	})
+	if err != nil {
+		return err
+	}
+
+	newKey := output.AccessKey
+	logger.Success("Created access key %s", logger.Bold(aws.ToString(newKey.AccessKeyId)))
+
+	// Undoes the rotation while the old key is still active
+	rollback := func(original []byte) {
+		if original != nil {
+			if err := ioutil.WriteFile(credentialsFile, original, 0600); err != nil {
+				logger.Error("Unable to restore %s. Error: %s", credentialsFile, err.Error())
+			}
+		}
+
+		_, err := iamHandler.DeleteAccessKey(ctx, &iam.DeleteAccessKeyInput{
+			UserName:    aws.String(userName),
+			AccessKeyId: newKey.AccessKeyId,
+		})
+		if err != nil {
+			logger.Error("Unable to delete new access key %s. Error: %s", aws.ToString(newKey.AccessKeyId), err.Error())
+		}
+	}
+
+	original, err := updateCredentialsFile(credentialsFile, section, aws.ToString(newKey.AccessKeyId), aws.ToString(newKey.SecretAccessKey))
+	if err != nil {
+		rollback(nil)
+		return errors.New("unable to update " + credentialsFile + ". Error: " + err.Error())
+	}
+	logger.Success("Updated profile %s in %s", logger.Bold(profile), credentialsFile)
+
+	newCfg, err := verifyAccessKey(ctx, cfg, aws.ToString(newKey.AccessKeyId), aws.ToString(newKey.SecretAccessKey), userName)
+	if err != nil {
+		rollback(original)
+		return errors.New("unable to verify new access key, rotation rolled back. Error: " + err.Error())
+	}
+	logger.Success("Verified new access key")
+
+	// cfg is signed with the old key, which stops working once deactivated
+	iamHandler = iam.NewFromConfig(newCfg)
+
+	// Deactivating first makes sure nothing still relies on the old key before it is gone for good
+	_, err = iamHandler.UpdateAccessKey(ctx, &iam.UpdateAccessKeyInput{
+		UserName:    aws.String(userName),
+		AccessKeyId: aws.String(current.AccessKeyID),
+		Status:      types.StatusTypeInactive,
+	})
+	if err != nil {
+		return fmt.Errorf("new key is in use but unable to deactivate old key %s. Error: %s", current.AccessKeyID, err.Error())
+	}
+
+	_, err = iamHandler.DeleteAccessKey(ctx, &iam.DeleteAccessKeyInput{
+		UserName:    aws.String(userName),
+		AccessKeyId: aws.String(current.AccessKeyID),
+	})
+	if err != nil {
+		return fmt.Errorf("new key is in use and old key %s is inactive but unable to delete it. Error: %s", current.AccessKeyID, err.Error())
+	}
+
+	logger.Success("Deactivated and deleted old access key %s", logger.Bold(current.AccessKeyID))
+	return nil
+}
+
+// verifyAccessKey calls sts with only the given key until it succeeds as the user or the timeout passes,
+// and returns a copy of cfg using the key
+func verifyAccessKey(ctx context.Context, cfg aws.Config, accessKeyID, secretAccessKey, userName string) (aws.Config, error) {
+	staticCfg := cfg.Copy()
+	staticCfg.Credentials = credentials.NewStaticCredentialsProvider(accessKeyID, secretAccessKey, "")
+
+	start := time.Now()
+	for {
+		identity, err := GetCallerIdentity(ctx, staticCfg)
+		if err == nil {
+			if identity.PrincipalType != PrincipalUser || identity.Name != userName {
+				return staticCfg, errors.New("new key belongs to " + identity.Arn)
+			}
+
+			return staticCfg, nil
+		}
+
+		if time.Since(start) > keyVerificationTimeout {
+			return staticCfg, err
+		}
+
+		time.Sleep(keyVerificationInterval)
+	}
+}
+
+// updateCredentialsFile sets the access key of the section in an ini formatted credentials file, keeping everything
+// else as is, and returns the original contents
+func updateCredentialsFile(path, section, accessKeyID, secretAccessKey string) ([]byte, error) {
+	original, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{
+		"aws_access_key_id":     accessKeyID,
+		"aws_secret_access_key": secretAccessKey,
+	}
+
+	lines := strings.Split(string(original), "\n")
+	updated := make([]string, 0)
+
+	inSection, found := false, false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			// Keys missing from the section are added at its end
+			if inSection {
+				updated = appendMissingKeys(updated, values)
+			}
+
+			inSection = strings.TrimSpace(trimmed[1:len(trimmed)-1]) == section
+			found = found || inSection
+			updated = append(updated, line)
+			continue
+		}
+
+		if inSection {
+			key := strings.ToLower(strings.TrimSpace(strings.SplitN(trimmed, "=", 2)[0]))
+			// A session token left over from temporary credentials would be sent along with the new key
+			if key == "aws_session_token" {
+				continue
+			}
+
+			if value, ok := values[key]; ok {
+				updated = append(updated, key+" = "+value)
+				delete(values, key)
+				continue
+			}
+		}
+
+		updated = append(updated, line)
+	}
+
+	if inSection {
+		updated = appendMissingKeys(updated, values)
+	}
+
+	if !found {
+		return nil, errors.New("profile section [" + section + "] not found")
+	}
+
+	return original, ioutil.WriteFile(path, []byte(strings.Join(updated, "\n")), 0600)
+}
+
+func appendMissingKeys(lines []string, values map[string]string) []string {
+	for _, key := range []string{"aws_access_key_id", "aws_secret_access_key"} {
+		if value, ok := values[key]; ok {
+			lines = append(lines, key+" = "+value)
+			delete(values, key)
+		}
+	}
+
+	return lines
+}
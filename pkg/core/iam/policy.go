@@ -0,0 +1,132 @@
+package iam
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+// Denial is an action the simulation did not allow
+type Denial struct {
+	Action   string
+	Resource string
+	Decision types.PolicyEvaluationDecisionType
+	// MissingContextValues are condition keys the decision depends on which were not given to the simulation,
+	// e.g. aws:MultiFactorAuthPresent. Real requests carry them and may well be allowed.
+	MissingContextValues []string
+}
+
+func (d Denial) String() string {
+	denial := d.Action
+	if d.Resource != "" && d.Resource != "*" {
+		denial += " on " + d.Resource
+	}
+
+	denial += " (" + string(d.Decision)
+	if len(d.MissingContextValues) > 0 {
+		denial += ", depends on " + strings.Join(d.MissingContextValues, ", ")
+	}
+
+	return denial + ")"
+}
+
+// Certain reports whether the decision does not depend on request context unknown to the simulation
+func (d Denial) Certain() bool {
+	return len(d.MissingContextValues) == 0
+}
+
+// SourceIPContext is the aws:SourceIp condition key for the given ip, which may be in CIDR notation
+func SourceIPContext(ip string) types.ContextEntry {
+	return types.ContextEntry{
+		ContextKeyName:   aws.String("aws:SourceIp"),
+		ContextKeyType:   types.ContextKeyTypeEnumIp,
+		ContextKeyValues: []string{strings.TrimSuffix(ip, "/32")},
+	}
+}
+
+// requestContext is the condition context known for any request made with cfg
+func requestContext(cfg aws.Config) []types.ContextEntry {
+	return []types.ContextEntry{
+		{
+			ContextKeyName:   aws.String("aws:RequestedRegion"),
+			ContextKeyType:   types.ContextKeyTypeEnumString,
+			ContextKeyValues: []string{cfg.Region},
+		},
+		{
+			ContextKeyName:   aws.String("aws:SecureTransport"),
+			ContextKeyType:   types.ContextKeyTypeEnumBoolean,
+			ContextKeyValues: []string{"true"},
+		},
+		{
+			ContextKeyName:   aws.String("aws:CurrentTime"),
+			ContextKeyType:   types.ContextKeyTypeEnumDate,
+			ContextKeyValues: []string{time.Now().UTC().Format(time.RFC3339)},
+		},
+	}
+}
+
+// SimulatePrincipalPolicy returns the actions (with resource if given) which are not allowed for the principal
+// with the given condition context
+func SimulatePrincipalPolicy(ctx context.Context, cfg aws.Config, principalArn string, actions, resources []string, contextEntries []types.ContextEntry) ([]Denial, error) {
+	iamHandler := iam.NewFromConfig(cfg)
+
+	denied := make([]Denial, 0)
+
+	var marker *string
+	for {
+		output, err := iamHandler.SimulatePrincipalPolicy(ctx, &iam.SimulatePrincipalPolicyInput{
+			PolicySourceArn: aws.String(principalArn),
+			ActionNames:     actions,
+			ResourceArns:    resources,
+			ContextEntries:  contextEntries,
+			Marker:          marker,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, result := range output.EvaluationResults {
+			if result.EvalDecision == types.PolicyEvaluationDecisionTypeAllowed {
+				continue
+			}
+
+			// Context values missing for specific resources are only reported per resource
+			missing := result.MissingContextValues
+			for _, resourceResult := range result.ResourceSpecificResults {
+				missing = append(missing, resourceResult.MissingContextValues...)
+			}
+
+			denied = append(denied, Denial{
+				Action:               aws.ToString(result.EvalActionName),
+				Resource:             aws.ToString(result.EvalResourceName),
+				Decision:             result.EvalDecision,
+				MissingContextValues: unique(missing),
+			})
+		}
+
+		if !output.IsTruncated {
+			break
+		}
+
+		marker = output.Marker
+	}
+
+	return denied, nil
+}
+
+func unique(values []string) []string {
+	seen := make(map[string]bool)
+	uniqueValues := make([]string, 0)
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			uniqueValues = append(uniqueValues, value)
+		}
+	}
+
+	return uniqueValues
+}
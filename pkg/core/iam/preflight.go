@@ -0,0 +1,117 @@
+package iam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"bitbucket.org/agrim123/onyx/pkg/logger"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+// PrincipalArn returns the IAM arn the policies of the identity can be simulated against.
+// For assumed roles, this is the role arn including its path, which the sts arn does not carry.
+func (i *Identity) PrincipalArn(ctx context.Context, cfg aws.Config) (string, error) {
+	switch i.PrincipalType {
+	case PrincipalUser:
+		return i.Arn, nil
+	case PrincipalAssumedRole:
+		iamHandler := iam.NewFromConfig(cfg)
+		output, err := iamHandler.GetRole(ctx, &iam.GetRoleInput{
+			RoleName: aws.String(i.Name),
+		})
+		if err != nil {
+			return "", err
+		}
+
+		return aws.ToString(output.Role.Arn), nil
+	}
+
+	return "", errors.New("policies of " + i.PrincipalType + " principals cannot be simulated")
+}
+
+// Preflight verifies the identity is allowed to perform the actions on the resources (all resources if none given)
+// before a mutating command starts, so it does not fail with AccessDenied midway. Denied actions are returned as an
+// error. Denials depending on condition keys the simulation was not given (e.g. MFA or VPC conditions) are only warned
+// about, since real requests carry them and may well succeed. Context entries known to the caller, such as the source
+// ip, narrow this down. If the permissions cannot be verified, e.g. iam:SimulatePrincipalPolicy is not allowed, a
+// warning is logged and nil returned.
+func (i *Identity) Preflight(ctx context.Context, cfg aws.Config, actions, resources []string, contextEntries ...types.ContextEntry) error {
+	principalArn, err := i.PrincipalArn(ctx, cfg)
+	if err != nil {
+		logger.Warn("Unable to verify permissions. Error: %s", err.Error())
+		return nil
+	}
+
+	denials, err := SimulatePrincipalPolicy(ctx, cfg, principalArn, actions, resources, append(requestContext(cfg), contextEntries...))
+	if err != nil {
+		logger.Warn("Unable to verify permissions. Error: %s", err.Error())
+		return nil
+	}
+
+	denied := make([]string, 0)
+	for _, denial := range denials {
+		if denial.Certain() {
+			denied = append(denied, denial.String())
+		} else {
+			logger.Warn("%s may not be allowed to perform %s", i.Arn, denial.String())
+		}
+	}
+
+	if len(denied) > 0 {
+		return fmt.Errorf("%s is missing permissions: %s", i.Arn, strings.Join(denied, ", "))
+	}
+
+	return nil
+}
+
+// Preflight verifies the caller is allowed to perform the actions on the resources, see Identity.Preflight
+func Preflight(ctx context.Context, cfg aws.Config, actions, resources []string, contextEntries ...types.ContextEntry) error {
+	identity, err := GetCallerIdentity(ctx, cfg)
+	if err != nil {
+		logger.Warn("Unable to verify permissions. Error: %s", err.Error())
+		return nil
+	}
+
+	return identity.Preflight(ctx, cfg, actions, resources, contextEntries...)
+}
+
+// Can prints whether the caller is allowed to perform each of the actions on the resources and reports if all are
+// allowed. Denials depending on request context unknown to the simulation are reported but do not count.
+func Can(ctx context.Context, cfg aws.Config, actions, resources []string) (bool, error) {
+	identity, err := GetCallerIdentity(ctx, cfg)
+	if err != nil {
+		return false, err
+	}
+
+	principalArn, err := identity.PrincipalArn(ctx, cfg)
+	if err != nil {
+		return false, err
+	}
+
+	denials, err := SimulatePrincipalPolicy(ctx, cfg, principalArn, actions, resources, requestContext(cfg))
+	if err != nil {
+		return false, err
+	}
+
+	fmt.Println("Principal:", principalArn)
+	if len(denials) == 0 {
+		logger.Success("Allowed: %s", strings.Join(actions, ", "))
+		return true, nil
+	}
+
+	allowed := true
+	for _, denial := range denials {
+		if denial.Certain() {
+			logger.Error("Denied: %s", denial.String())
+			allowed = false
+		} else {
+			logger.Warn("Unverified: %s", denial.String())
+		}
+	}
+
+	return allowed, nil
+}
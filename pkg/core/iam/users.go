@@ -0,0 +1,336 @@
+package iam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"bitbucket.org/agrim123/onyx/pkg/logger"
+	"bitbucket.org/agrim123/onyx/pkg/utils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+// ErrUserNotFound is returned by GetUser when there is no IAM user with the name, e.g. for SSO users
+var ErrUserNotFound = errors.New("iam user not found")
+
+// Policy is a managed or inline policy applying to a user, directly or through a group
+type Policy struct {
+	Name   string
+	Inline bool
+	// Group is the group the policy is attached to, empty for policies of the user
+	Group string
+}
+
+type AccessKey struct {
+	ID        string
+	Status    types.StatusType
+	CreatedAt time.Time
+	LastUsed  *time.Time
+}
+
+// User holds what an IAM user can access and when it was last active
+type User struct {
+	Name             string
+	Arn              string
+	CreatedAt        time.Time
+	ConsoleAccess    bool
+	PasswordLastUsed *time.Time
+	Groups           []string
+	Policies         []Policy
+	MFADevices       []string
+	AccessKeys       []AccessKey
+}
+
+// GetUser describes the IAM user along with its groups, policies, MFA devices and access keys
+func GetUser(ctx context.Context, cfg aws.Config, name string) (*User, error) {
+	iamHandler := iam.NewFromConfig(cfg)
+
+	output, err := iamHandler.GetUser(ctx, &iam.GetUserInput{
+		UserName: aws.String(name),
+	})
+	if err != nil {
+		var notFound *types.NoSuchEntityException
+		if errors.As(err, &notFound) {
+			return nil, ErrUserNotFound
+		}
+
+		return nil, err
+	}
+
+	user := &User{
+		Name:             aws.ToString(output.User.UserName),
+		Arn:              aws.ToString(output.User.Arn),
+		CreatedAt:        aws.ToTime(output.User.CreateDate),
+		PasswordLastUsed: output.User.PasswordLastUsed,
+		Groups:           make([]string, 0),
+		Policies:         make([]Policy, 0),
+		MFADevices:       make([]string, 0),
+		AccessKeys:       make([]AccessKey, 0),
+	}
+
+	// Users without a login profile cannot sign in to the console
+	_, err = iamHandler.GetLoginProfile(ctx, &iam.GetLoginProfileInput{
+		UserName: aws.String(name),
+	})
+	if err == nil {
+		user.ConsoleAccess = true
+	} else {
+		var notFound *types.NoSuchEntityException
+		if !errors.As(err, &notFound) {
+			return nil, err
+		}
+	}
+
+	if err := user.getGroups(ctx, iamHandler); err != nil {
+		return nil, err
+	}
+
+	policies, err := listPolicies(ctx, iamHandler, name, "")
+	if err != nil {
+		return nil, err
+	}
+	user.Policies = append(user.Policies, policies...)
+
+	for _, group := range user.Groups {
+		policies, err := listPolicies(ctx, iamHandler, "", group)
+		if err != nil {
+			return nil, err
+		}
+		user.Policies = append(user.Policies, policies...)
+	}
+
+	if err := user.getMFADevices(ctx, iamHandler); err != nil {
+		return nil, err
+	}
+
+	keys, err := listAccessKeys(ctx, cfg, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		accessKey := AccessKey{
+			ID:        aws.ToString(key.AccessKeyId),
+			Status:    key.Status,
+			CreatedAt: aws.ToTime(key.CreateDate),
+		}
+
+		output, err := iamHandler.GetAccessKeyLastUsed(ctx, &iam.GetAccessKeyLastUsedInput{
+			AccessKeyId: key.AccessKeyId,
+		})
+		if err != nil {
+			logger.Warn("Unable to get last use of %s. Error: %s", accessKey.ID, err.Error())
+		} else if output.AccessKeyLastUsed != nil {
+			accessKey.LastUsed = output.AccessKeyLastUsed.LastUsedDate
+		}
+
+		user.AccessKeys = append(user.AccessKeys, accessKey)
+	}
+
+	return user, nil
+}
+
+func (u *User) getGroups(ctx context.Context, iamHandler *iam.Client) error {
+	var marker *string
+	for {
+		output, err := iamHandler.ListGroupsForUser(ctx, &iam.ListGroupsForUserInput{
+			UserName: aws.String(u.Name),
+			Marker:   marker,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, group := range output.Groups {
+			u.Groups = append(u.Groups, aws.ToString(group.GroupName))
+		}
+
+		if !output.IsTruncated {
+			break
+		}
+
+		marker = output.Marker
+	}
+
+	return nil
+}
+
+func (u *User) getMFADevices(ctx context.Context, iamHandler *iam.Client) error {
+	var marker *string
+	for {
+		output, err := iamHandler.ListMFADevices(ctx, &iam.ListMFADevicesInput{
+			UserName: aws.String(u.Name),
+			Marker:   marker,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, device := range output.MFADevices {
+			u.MFADevices = append(u.MFADevices, aws.ToString(device.SerialNumber))
+		}
+
+		if !output.IsTruncated {
+			break
+		}
+
+		marker = output.Marker
+	}
+
+	return nil
+}
+
+// listPolicies returns the managed and inline policies of the user, or of the group if userName is empty
+func listPolicies(ctx context.Context, iamHandler *iam.Client, userName, groupName string) ([]Policy, error) {
+	policies := make([]Policy, 0)
+
+	var marker *string
+	for {
+		var attached []types.AttachedPolicy
+		var truncated bool
+		if userName != "" {
+			output, err := iamHandler.ListAttachedUserPolicies(ctx, &iam.ListAttachedUserPoliciesInput{
+				UserName: aws.String(userName),
+				Marker:   marker,
+			})
+			if err != nil {
+				return nil, err
+			}
+			attached, truncated, marker = output.AttachedPolicies, output.IsTruncated, output.Marker
+		} else {
+			output, err := iamHandler.ListAttachedGroupPolicies(ctx, &iam.ListAttachedGroupPoliciesInput{
+				GroupName: aws.String(groupName),
+				Marker:    marker,
+			})
+			if err != nil {
+				return nil, err
+			}
+			attached, truncated, marker = output.AttachedPolicies, output.IsTruncated, output.Marker
+		}
+
+		for _, policy := range attached {
+			policies = append(policies, Policy{
+				Name:  aws.ToString(policy.PolicyName),
+				Group: groupName,
+			})
+		}
+
+		if !truncated {
+			break
+		}
+	}
+
+	marker = nil
+	for {
+		var names []string
+		var truncated bool
+		if userName != "" {
+			output, err := iamHandler.ListUserPolicies(ctx, &iam.ListUserPoliciesInput{
+				UserName: aws.String(userName),
+				Marker:   marker,
+			})
+			if err != nil {
+				return nil, err
+			}
+			names, truncated, marker = output.PolicyNames, output.IsTruncated, output.Marker
+		} else {
+			output, err := iamHandler.ListGroupPolicies(ctx, &iam.ListGroupPoliciesInput{
+				GroupName: aws.String(groupName),
+				Marker:    marker,
+			})
+			if err != nil {
+				return nil, err
+			}
+			names, truncated, marker = output.PolicyNames, output.IsTruncated, output.Marker
+		}
+
+		for _, name := range names {
+			policies = append(policies, Policy{
+				Name:   name,
+				Inline: true,
+				Group:  groupName,
+			})
+		}
+
+		if !truncated {
+			break
+		}
+	}
+
+	return policies, nil
+}
+
+// LastActivity is the latest of console sign in and access key use, nil if the user was never active
+func (u *User) LastActivity() *time.Time {
+	last := u.PasswordLastUsed
+	for _, key := range u.AccessKeys {
+		if key.LastUsed != nil && (last == nil || key.LastUsed.After(*last)) {
+			last = key.LastUsed
+		}
+	}
+
+	return last
+}
+
+func timeAgo(t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+
+	return utils.HumanizeDuration(time.Since(*t)) + " ago"
+}
+
+func (u *User) Print() {
+	fmt.Println("User Name:    ", logger.Bold(u.Name))
+	fmt.Println("ARN:          ", u.Arn)
+	fmt.Println("Created:      ", timeAgo(&u.CreatedAt))
+	fmt.Println("Last activity:", timeAgo(u.LastActivity()))
+
+	if u.ConsoleAccess {
+		fmt.Println("Console:       enabled, last sign in", timeAgo(u.PasswordLastUsed))
+	} else {
+		fmt.Println("Console:       disabled")
+	}
+
+	if len(u.MFADevices) > 0 {
+		fmt.Println("MFA:          ", logger.Green("enabled"), u.MFADevices)
+	} else if u.ConsoleAccess {
+		fmt.Println("MFA:          ", logger.Red("disabled"))
+	} else {
+		fmt.Println("MFA:           disabled")
+	}
+
+	fmt.Println("Groups:")
+	for _, group := range u.Groups {
+		fmt.Println("  ", group)
+	}
+
+	fmt.Println("Policies:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, policy := range u.Policies {
+		kind := "managed"
+		if policy.Inline {
+			kind = "inline"
+		}
+
+		via := "user"
+		if policy.Group != "" {
+			via = "group " + policy.Group
+		}
+
+		fmt.Fprintf(w, "   %s\t%s\t%s\n", policy.Name, kind, via)
+	}
+	w.Flush()
+
+	fmt.Println("Access keys:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, key := range u.AccessKeys {
+		fmt.Fprintf(w, "   %s\t%s\tcreated %s\tlast used %s\n", key.ID, key.Status, timeAgo(&key.CreatedAt), timeAgo(key.LastUsed))
+	}
+	w.Flush()
+}
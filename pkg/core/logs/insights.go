@@ -0,0 +1,238 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"bitbucket.org/agrim123/onyx/pkg/config"
+	"bitbucket.org/agrim123/onyx/pkg/logger"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchlogsLib "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+const (
+	queryPollInterval = time.Second
+	queryTimeout      = 15 * time.Minute
+	// StartQuery accepts at most this many log groups
+	maxQueryGroups = 20
+)
+
+type QueryInput struct {
+	Groups []string
+	Query  string
+	Start  time.Time
+	End    time.Time
+	Limit  int32
+}
+
+// QueryResult holds the rows of a Logs Insights query, with fields in the order they first appear
+type QueryResult struct {
+	Fields         []string            `json:"fields"`
+	Rows           []map[string]string `json:"rows"`
+	RecordsMatched float64             `json:"records_matched"`
+	RecordsScanned float64             `json:"records_scanned"`
+	BytesScanned   float64             `json:"bytes_scanned"`
+}
+
+// RunQuery starts a Logs Insights query and polls until it completes
+func RunQuery(ctx context.Context, cfg aws.Config, input QueryInput) (*QueryResult, error) {
+	if len(input.Groups) == 0 {
+		return nil, errors.New("at least one log group is required")
+	}
+
+	if len(input.Groups) > maxQueryGroups {
+		return nil, fmt.Errorf("a query can search at most %d log groups", maxQueryGroups)
+	}
+
+	if strings.TrimSpace(input.Query) == "" {
+		return nil, errors.New("empty query")
+	}
+
+	startQueryInput := &cloudwatchlogsLib.StartQueryInput{
+		LogGroupNames: input.Groups,
+		QueryString:   aws.String(input.Query),
+		StartTime:     aws.Int64(input.Start.Unix()),
+		EndTime:       aws.Int64(input.End.Unix()),
+	}
+	if input.Limit > 0 {
+		startQueryInput.Limit = aws.Int32(input.Limit)
+	}
+
+	cloudwatchlogsHandler := cloudwatchlogsLib.NewFromConfig(cfg)
+	startOutput, err := cloudwatchlogsHandler.StartQuery(ctx, startQueryInput)
+	if err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	for {
+		output, err := cloudwatchlogsHandler.GetQueryResults(ctx, &cloudwatchlogsLib.GetQueryResultsInput{
+			QueryId: startOutput.QueryId,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		switch output.Status {
+		case types.QueryStatusComplete:
+			return newQueryResult(output), nil
+		case types.QueryStatusScheduled, types.QueryStatusRunning:
+		default:
+			// Failed, Cancelled, Timeout or Unknown, none of which will complete
+			return nil, fmt.Errorf("query %s", strings.ToLower(string(output.Status)))
+		}
+
+		if time.Since(started) > queryTimeout {
+			_, err := cloudwatchlogsHandler.StopQuery(ctx, &cloudwatchlogsLib.StopQueryInput{
+				QueryId: startOutput.QueryId,
+			})
+			if err != nil {
+				logger.Warn("Unable to stop query %s. Error: %s", aws.ToString(startOutput.QueryId), err.Error())
+			}
+			return nil, errors.New("timed out waiting for query to complete")
+		}
+
+		time.Sleep(queryPollInterval)
+	}
+}
+
+func newQueryResult(output *cloudwatchlogsLib.GetQueryResultsOutput) *QueryResult {
+	result := &QueryResult{
+		Fields: make([]string, 0),
+		Rows:   make([]map[string]string, 0),
+	}
+
+	if output.Statistics != nil {
+		result.RecordsMatched = output.Statistics.RecordsMatched
+		result.RecordsScanned = output.Statistics.RecordsScanned
+		result.BytesScanned = output.Statistics.BytesScanned
+	}
+
+	seen := make(map[string]bool)
+	for _, fields := range output.Results {
+		row := make(map[string]string)
+		for _, field := range fields {
+			name := aws.ToString(field.Field)
+			// @ptr only identifies the record for GetLogRecord
+			if name == "@ptr" {
+				continue
+			}
+
+			if !seen[name] {
+				seen[name] = true
+				result.Fields = append(result.Fields, name)
+			}
+
+			row[name] = aws.ToString(field.Value)
+		}
+
+		result.Rows = append(result.Rows, row)
+	}
+
+	return result
+}
+
+// Print renders the rows as a table
+func (r *QueryResult) Print() {
+	if len(r.Rows) == 0 {
+		logger.Info("No results. Scanned %.0f records", r.RecordsScanned)
+		return
+	}
+
+	// Tabs and new lines in messages would break the table
+	replacer := strings.NewReplacer("\t", " ", "\n", " ", "\r", "")
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(r.Fields, "\t"))
+	for _, row := range r.Rows {
+		values := make([]string, 0)
+		for _, field := range r.Fields {
+			values = append(values, replacer.Replace(row[field]))
+		}
+		fmt.Fprintln(w, strings.Join(values, "\t"))
+	}
+	w.Flush()
+
+	logger.Info("%d row(s). Matched %.0f of %.0f records scanned", len(r.Rows), r.RecordsMatched, r.RecordsScanned)
+}
+
+// PrintJSON writes the result as JSON
+func (r *QueryResult) PrintJSON() error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// GetSavedQuery returns the named query from the onyx config
+func GetSavedQuery(name string) (*config.SavedQuery, error) {
+	onyxConfig, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	query, ok := onyxConfig.Queries[name]
+	if !ok {
+		return nil, errors.New("no saved query named " + name + ". See `onyx logs queries`")
+	}
+
+	return &query, nil
+}
+
+// SaveQuery stores the query along with its log groups under name in the onyx config
+func SaveQuery(name, query string, groups []string) error {
+	onyxConfig, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	if onyxConfig.Queries == nil {
+		onyxConfig.Queries = make(map[string]config.SavedQuery)
+	}
+
+	onyxConfig.Queries[name] = config.SavedQuery{
+		Query:  query,
+		Groups: groups,
+	}
+
+	if err := onyxConfig.Save(); err != nil {
+		return err
+	}
+
+	logger.Success("Saved query %s", logger.Bold(name))
+	return nil
+}
+
+// PrintSavedQueries lists the named queries in the onyx config
+func PrintSavedQueries() error {
+	onyxConfig, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	if len(onyxConfig.Queries) == 0 {
+		logger.Info("No saved queries. Save one with `onyx logs query --save <name>`")
+		return nil
+	}
+
+	names := make([]string, 0)
+	for name := range onyxConfig.Queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		query := onyxConfig.Queries[name]
+		fmt.Println(logger.Bold(name), "("+strings.Join(query.Groups, ", ")+")")
+		fmt.Println("   ", query.Query)
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"bitbucket.org/agrim123/onyx/pkg/logger"
+	"bitbucket.org/agrim123/onyx/pkg/utils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchlogsLib "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+const followPollInterval = 2 * time.Second
+
+// Source is a log stream of a log group, printed with Label as prefix
+type Source struct {
+	// Region is where the log group is, the region of the config passed to Tail if empty
+	Region string
+	Group  string
+	Stream string
+	Label  string
+}
+
+type TailInput struct {
+	Sources []Source
+	Start   time.Time
+	Follow  bool
+	// Grep, if set, drops messages not matching it
+	Grep *regexp.Regexp
+	// Until, if set, stops following once it returns true
+	Until func() bool
+	// Refresh, if set, is called before each poll while following to pick up sources started since
+	Refresh func() ([]Source, error)
+}
+
+// logGroup identifies a log group across regions
+type logGroup struct {
+	region string
+	name   string
+}
+
+type event struct {
+	id        string
+	label     string
+	timestamp int64
+	message   string
+}
+
+// Tail prints events of all sources interleaved by timestamp, optionally following them for new events
+func Tail(ctx context.Context, cfg aws.Config, input TailInput) error {
+	if len(input.Sources) == 0 {
+		return errors.New("no log streams to read")
+	}
+
+	groups, labels := groupSources(cfg, input.Sources)
+
+	start := input.Start.UnixNano() / int64(time.Millisecond)
+	seen := make(map[string]bool)
+	for {
+		stop := input.Until != nil && input.Until()
+		if stop {
+			// Gives the last events time to get ingested
+			time.Sleep(followPollInterval)
+		}
+
+		events, err := fetchEvents(ctx, cfg, groups, labels, start)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range events {
+			if seen[e.id] {
+				continue
+			}
+
+			// Only ids at the latest timestamp can show up again on the next poll
+			if e.timestamp > start {
+				start = e.timestamp
+				seen = make(map[string]bool)
+			}
+			seen[e.id] = true
+
+			if input.Grep != nil && !input.Grep.MatchString(e.message) {
+				continue
+			}
+
+			fmt.Println(logger.Bold("["+e.label+"]"), time.Unix(0, e.timestamp*int64(time.Millisecond)).Format("15:04:05"), strings.TrimRight(e.message, "\n"))
+		}
+
+		if !input.Follow || stop {
+			return nil
+		}
+
+		time.Sleep(followPollInterval)
+
+		if input.Refresh != nil {
+			sources, err := input.Refresh()
+			if err != nil {
+				logger.Warn("Unable to refresh log streams. Error: %s", err.Error())
+			} else if len(sources) > 0 {
+				groups, labels = groupSources(cfg, sources)
+			}
+		}
+	}
+}
+
+// groupSources groups the streams by log group, as events are filtered per group, and maps them to their labels
+func groupSources(cfg aws.Config, sources []Source) (map[logGroup][]string, map[string]string) {
+	groups := make(map[logGroup][]string)
+	labels := make(map[string]string)
+	for _, source := range sources {
+		group := logGroup{region: source.Region, name: source.Group}
+		if group.region == "" {
+			group.region = cfg.Region
+		}
+
+		groups[group] = append(groups[group], source.Stream)
+		labels[group.region+"/"+group.name+"/"+source.Stream] = source.Label
+	}
+
+	return groups, labels
+}
+
+func fetchEvents(ctx context.Context, cfg aws.Config, groups map[logGroup][]string, labels map[string]string, start int64) ([]event, error) {
+	events := make([]event, 0)
+	for group, streams := range groups {
+		groupCfg := cfg.Copy()
+		groupCfg.Region = group.region
+		cloudwatchlogsHandler := cloudwatchlogsLib.NewFromConfig(groupCfg)
+
+		for _, chunk := range utils.GetChunks(streams, 100) {
+			var nextToken *string
+			for {
+				output, err := cloudwatchlogsHandler.FilterLogEvents(ctx, &cloudwatchlogsLib.FilterLogEventsInput{
+					LogGroupName:   aws.String(group.name),
+					LogStreamNames: chunk,
+					StartTime:      aws.Int64(start),
+					NextToken:      nextToken,
+				})
+				if err != nil {
+					var notFound *types.ResourceNotFoundException
+					if errors.As(err, &notFound) {
+						break
+					}
+
+					return nil, err
+				}
+
+				for _, e := range output.Events {
+					events = append(events, event{
+						id:        aws.ToString(e.EventId),
+						label:     labels[group.region+"/"+group.name+"/"+aws.ToString(e.LogStreamName)],
+						timestamp: aws.ToInt64(e.Timestamp),
+						message:   aws.ToString(e.Message),
+					})
+				}
+
+				if output.NextToken == nil {
+					break
+				}
+
+				nextToken = output.NextToken
+			}
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].timestamp < events[j].timestamp
+	})
+
+	return events, nil
+}
@@ -18,6 +18,10 @@ func Green(message interface{}) string {
 	return color.New(color.FgGreen).Sprint(message)
 }
 
+func Yellow(message interface{}) string {
+	return color.New(color.FgYellow).Sprint(message)
+}
+
 func Italic(message string) string {
 	return color.New(color.Italic).Sprint(message)
 }
@@ -2,10 +2,14 @@ package utils
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"bitbucket.org/agrim123/onyx/pkg/logger"
 )
@@ -77,3 +81,173 @@ func GetUserInput(message string) string {
 	input, _ := consoleReader.ReadString('\n')
 	return input
 }
+
+// ParseSelection converts user choices like `0,2`, `1-3` or `all` into unique indexes of a list of the given length
+func ParseSelection(input string, length int) ([]int, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, errors.New("empty choice")
+	}
+
+	if strings.ToLower(input) == "all" {
+		indexes := make([]int, length)
+		for i := range indexes {
+			indexes[i] = i
+		}
+		return indexes, nil
+	}
+
+	seen := make(map[int]bool)
+	indexes := make([]int, 0)
+	for _, choice := range strings.Split(input, ",") {
+		choice = strings.TrimSpace(choice)
+		if choice == "" {
+			continue
+		}
+
+		start, end := choice, choice
+		if i := strings.Index(choice, "-"); i > 0 {
+			start, end = choice[:i], choice[i+1:]
+		}
+
+		from, err := strconv.Atoi(strings.TrimSpace(start))
+		if err != nil {
+			return nil, fmt.Errorf("invalid choice: %s", choice)
+		}
+
+		to, err := strconv.Atoi(strings.TrimSpace(end))
+		if err != nil {
+			return nil, fmt.Errorf("invalid choice: %s", choice)
+		}
+
+		if from > to || from < 0 || to >= length {
+			return nil, fmt.Errorf("choice out of range: %s. Allowed range: 0-%d", choice, length-1)
+		}
+
+		for i := from; i <= to; i++ {
+			if !seen[i] {
+				seen[i] = true
+				indexes = append(indexes, i)
+			}
+		}
+	}
+
+	if len(indexes) == 0 {
+		return nil, errors.New("empty choice")
+	}
+
+	return indexes, nil
+}
+
+// SplitCommand splits a command line into arguments, honouring single and double quotes and backslash escapes
+func SplitCommand(command string) ([]string, error) {
+	args := make([]string, 0)
+
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range command {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote or escape in command: " + command)
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
+
+// JoinCommand joins arguments into a command line SplitCommand splits back into the same arguments,
+// single quoting the ones with whitespace, quotes, backslashes or nothing in them
+func JoinCommand(args []string) string {
+	quoted := make([]string, 0)
+	for _, arg := range args {
+		if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\") {
+			quoted = append(quoted, arg)
+			continue
+		}
+
+		// Single quotes cannot be escaped inside single quotes, so close, add an escaped quote and reopen
+		quoted = append(quoted, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// HumanizeDuration formats a duration with minute precision as days, hours and minutes, e.g. `3d 4h 5m`
+func HumanizeDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return "0m"
+	}
+
+	days := d / (24 * time.Hour)
+	hours := (d % (24 * time.Hour)) / time.Hour
+	minutes := (d % time.Hour) / time.Minute
+
+	parts := make([]string, 0)
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	if minutes > 0 {
+		parts = append(parts, fmt.Sprintf("%dm", minutes))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders values between 0 and max as a line of block characters
+func Sparkline(values []float64, max float64) string {
+	line := make([]rune, 0)
+	for _, value := range values {
+		i := 0
+		if max > 0 {
+			i = int(value / max * float64(len(sparkTicks)-1))
+		}
+
+		if i < 0 {
+			i = 0
+		} else if i >= len(sparkTicks) {
+			i = len(sparkTicks) - 1
+		}
+
+		line = append(line, sparkTicks[i])
+	}
+
+	return string(line)
+}